@@ -7,37 +7,83 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
 	"mime/multipart"
 	"net/http"
-	"net/http/httputil"
 	"os"
 	"path/filepath"
 	"reflect"
 	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
-	"github.com/PaesslerAG/jsonpath"
 	"github.com/cucumber/godog"
+	"github.com/gorilla/websocket"
 	"github.com/xeipuuv/gojsonschema"
 )
 
 // The defaults path to json schema files for validating the responses.
 const defaultSchemasPath = "schemas"
 
+// The defaults path to XSD files for validating the XML responses.
+const defaultXMLSchemasPath = "schemas"
+
 // ApiContext main struct
 type ApiContext struct {
 	baseURL         string
 	jSONSchemasPath string
-	debug           bool
+	xmlSchemasPath  string
+	xsdValidator    XSDValidator
+	pathDialect     PathDialect
+	bodyDecoders    map[string]BodyDecoder
 	client          *http.Client
-	headers         map[string]string
-	queryParams     map[string]string
+	headers         http.Header
+	scenarioHeaders map[string]bool
+	removedHeaders  map[string]bool
+	queryParams     []queryParam
+	queryArrayStyle QueryArrayStyle
+	rawQuery        string
+	pathParams      map[string]string
 	lastResponse    *ApiResponse
 	lastRequest     *http.Request
 	scope           map[string]string
+
+	graphQLVariables map[string]interface{}
+	sseStream        *sseStream
+	lastEvent        *SSEEvent
+	websocket        *websocketConn
+	mocks            map[string]*mockServer
+	services         map[string]*service
+	currentService   string
+	vcr              *vcrTransport
+
+	scenarioName  string
+	exchanges     []*exchange
+	curlOnFailure bool
+	harPath       string
+	harEntries    []harEntry
+
+	logger          Logger
+	logLevel        LogLevel
+	logBodyLimit    int
+	redactedHeaders map[string]bool
+	logOnFailure    bool
+	logBuffer       []LogEntry
+
+	secrets              []string
+	secretScopeVariables map[string]bool
+
+	profiles       map[string]Profile
+	defaultProfile string
+	activeProfile  string
+	profileErr     error
+	profileHeaders http.Header
+	profileBase    *profileBase
+	profileScope   []string
+	defaultHeaders http.Header
+
+	autoContentType bool
+	defaultAccept   string
+	cacheValidators *cacheValidators
 }
 
 // ApiResponse Struct that wraps an API response.
@@ -46,6 +92,11 @@ type ApiResponse struct {
 	StatusCode  int
 	Body        string
 	ResponseObj *http.Response
+
+	decoders   map[string]BodyDecoder
+	decoded    interface{}
+	decodeErr  error
+	hasDecoded bool
 }
 
 // New Creates a new instance of the API Context
@@ -53,24 +104,48 @@ func New(baseURL string) *ApiContext {
 	return &ApiContext{
 		baseURL:         baseURL,
 		client:          &http.Client{},
-		headers:         map[string]string{},
-		queryParams:     map[string]string{},
-		debug:           false,
+		headers:         http.Header{},
+		scenarioHeaders: map[string]bool{},
+		removedHeaders:  map[string]bool{},
+		defaultHeaders:  http.Header{},
+		queryArrayStyle: QueryArrayRepeat,
+		pathParams:      map[string]string{},
 		jSONSchemasPath: defaultSchemasPath,
+		xmlSchemasPath:  defaultXMLSchemasPath,
+		xsdValidator:    XmllintValidator{},
+		pathDialect:     JSONPathDialect,
+		bodyDecoders:    defaultBodyDecoders(),
 		scope:           map[string]string{},
+		mocks:           map[string]*mockServer{},
+		services:        map[string]*service{},
+		curlOnFailure:   true,
+		autoContentType: true,
+		logger:          StdLogger{},
+		logLevel:        LogLevelInfo,
+		redactedHeaders: newRedactedHeaders(),
+
+		secretScopeVariables: map[string]bool{},
 	}
 }
 
 // WithBaseURL Configures context base URL
 func (ctx *ApiContext) WithBaseURL(url string) *ApiContext {
 	ctx.baseURL = url
+	ctx.updateProfileBase(func(base *profileBase) {
+		base.baseURL = url
+	})
 
 	return ctx
 }
 
-// WithDebug Configures debug mode
+// WithDebug Configures debug mode, which logs the requests and responses. WithDebug(true) is a shortcut for WithLogLevel(LogLevelDebug),
+// and WithDebug(false) for WithLogLevel(LogLevelInfo).
 func (ctx *ApiContext) WithDebug(debug bool) *ApiContext {
-	ctx.debug = debug
+	if debug {
+		ctx.logLevel = LogLevelDebug
+	} else {
+		ctx.logLevel = LogLevelInfo
+	}
 
 	return ctx
 }
@@ -78,51 +153,190 @@ func (ctx *ApiContext) WithDebug(debug bool) *ApiContext {
 // WithJSONSchemasPath Specifies the path to JSON schema files for doing response validation
 func (ctx *ApiContext) WithJSONSchemasPath(path string) *ApiContext {
 	ctx.jSONSchemasPath = path
+	ctx.updateProfileBase(func(base *profileBase) {
+		base.jSONSchemasPath = path
+	})
 	return ctx
 }
 
 // InitializeScenario this function should be called when starting the Test suite, to register the available steps.
 func (ctx *ApiContext) InitializeScenario(s *godog.ScenarioContext) {
-	s.BeforeScenario(ctx.reset)
+	step := func(expr string, stepFunc interface{}) {
+		s.Step(expr, ctx.maskStepErrors(stepFunc))
+	}
 
-	s.Step(`^I set header "([^"]*)" with value "([^"]*)"$`, ctx.ISetHeaderWithValue)
-	s.Step(`^I set headers to:$`, ctx.ISetHeadersTo)
-	s.Step(`^I send "([^"]*)" request to "([^"]*)" with form body::$`, ctx.ISendRequestToWithFormBody)
-	s.Step(`^I send "([^"]*)" request to "([^"]*)" with body:$`, ctx.ISendRequestToWithBody)
-	s.Step(`^I send "([^"]*)" request to "([^"]*)"$`, ctx.ISendRequestTo)
-	s.Step(`^I set query param "([^"]*)" with value "([^"]*)"$`, ctx.ISetQueryParamWithValue)
-	s.Step(`^I set query params to:$`, ctx.ISetQueryParamsTo)
-	s.Step(`^The response code should be (\d+)$`, ctx.TheResponseCodeShouldBe)
-	s.Step(`^The response should be a valid json$`, ctx.TheResponseShouldBeAValidJSON)
-	s.Step(`^The response should match json:$`, ctx.TheResponseShouldMatchJSON)
-	s.Step(`^The response header "([^"]*)" should have value ([^"]*)$`, ctx.TheResponseHeaderShouldHaveValue)
-	s.Step(`^The response should match json schema "([^"]*)"$`, ctx.TheResponseShouldMatchJsonSchema)
-	s.Step(`^The json path "([^"]*)" should have value "([^"]*)"$`, ctx.TheJSONPathShouldHaveValue)
-	s.Step(`^The json path "([^"]*)" should match "([^"]*)"$`, ctx.TheJSONPathShouldMatch)
-	s.Step(`^The json path "([^"]*)" should have count "([^"]*)"$`, ctx.TheJSONPathHaveCount)
-	s.Step(`^The json path "([^"]*)" should be present"$`, ctx.TheJSONPathShouldBePresent)
-	s.Step(`^The response body should contain "([^"]*)"$`, ctx.TheResponseBodyShouldContain)
-	s.Step(`^The response body should match "([^"]*)"$`, ctx.TheResponseBodyShouldMatch)
-	s.Step(`^I wait for (\d+) seconds$`, ctx.WaitForSomeTime)
-	s.Step(`^I store data in scope variable "([^"]*)" with value "([^"]*)"`, ctx.StoreScopeData)
-	s.Step(`^I store the value of response header "([^"]*)" as "([^"]*)" in scenario scope$`, ctx.StoreResponseHeader)
-	s.Step(`^I store the value of body path "([^"]*)" as "([^"]*)" in scenario scope$`, ctx.StoreJsonPathValue)
-	s.Step(`^The scope variable "([^"]*)" should have value "([^"]*)"$`, ctx.TheScopeVariableShouldHaveValue)
+	s.BeforeScenario(ctx.reset)
+	s.AfterStep(func(st *godog.Step, err error) {
+		ctx.flushLogs(err != nil)
+	})
+	s.AfterScenario(func(sc *godog.Scenario, err error) {
+		if err != nil {
+			ctx.printCurlCommands()
+		}
+		ctx.closeSSEStream()
+		_ = ctx.closeWebsocket(websocket.CloseNormalClosure)
+	})
+
+	step(`^I set header "([^"]*)" with value "([^"]*)"$`, ctx.ISetHeaderWithValue)
+	step(`^I set headers to:$`, ctx.ISetHeadersTo)
+	step(`^I add header "([^"]*)" with value "([^"]*)"$`, ctx.IAddHeaderWithValue)
+	step(`^I remove header "([^"]*)"$`, ctx.IRemoveHeader)
+	step(`^I send "([^"]*)" request to "([^"]*)" as "([^"]*)" with body:$`, ctx.ISendRequestToAsWithBody)
+	step(`^I send "([^"]*)" request to "([^"]*)" as "([^"]*)"$`, ctx.ISendRequestToAs)
+	step(`^I send "([^"]*)" request to "([^"]*)" with form body::$`, ctx.ISendRequestToWithFormBody)
+	step(`^I send "([^"]*)" request to "([^"]*)" with body:$`, ctx.ISendRequestToWithBody)
+	step(`^I send "([^"]*)" request to "([^"]*)"$`, ctx.ISendRequestTo)
+	step(`^I send "([^"]*)" request to the link with rel "([^"]*)"$`, ctx.ISendRequestToTheLinkWithRel)
+	step(`^I send a conditional "([^"]*)" to "([^"]*)" with body:$`, ctx.ISendAConditionalToWithBody)
+	step(`^I send a conditional "([^"]*)" to "([^"]*)"$`, ctx.ISendAConditionalTo)
+	step(`^I store the cache validators of the response$`, ctx.IStoreTheCacheValidatorsOfTheResponse)
+	step(`^I send a CORS preflight for "([^"]*)" to "([^"]*)" from origin "([^"]*)" with headers "([^"]*)"$`, ctx.ISendACORSPreflightForToFromOriginWithHeaders)
+	step(`^I send a CORS preflight for "([^"]*)" to "([^"]*)" from origin "([^"]*)"$`, ctx.ISendACORSPreflightForToFromOrigin)
+	step(`^I use service "([^"]*)"$`, ctx.IUseService)
+	step(`^I set query param "([^"]*)" with value "([^"]*)"$`, ctx.ISetQueryParamWithValue)
+	step(`^I set query params to:$`, ctx.ISetQueryParamsTo)
+	step(`^I add query param "([^"]*)" with value "([^"]*)"$`, ctx.IAddQueryParamWithValue)
+	step(`^I set the query string to "([^"]*)"$`, ctx.ISetTheQueryStringTo)
+	step(`^I set path param "([^"]*)" with value "([^"]*)"$`, ctx.ISetPathParamWithValue)
+	step(`^I set GraphQL variables to:$`, ctx.ISetGraphQLVariablesTo)
+	step(`^I send a GraphQL query to "([^"]*)" with operation name "([^"]*)":$`, ctx.ISendAGraphQLQueryToWithOperationName)
+	step(`^I send a GraphQL query to "([^"]*)":$`, ctx.ISendAGraphQLQueryTo)
+	step(`^I open an SSE stream to "([^"]*)"$`, ctx.IOpenAnSSEStreamTo)
+	step(`^I should receive an event "([^"]*)" within (\d+) seconds$`, ctx.IShouldReceiveAnEventWithin)
+	step(`^I close the SSE stream$`, ctx.ICloseTheSSEStream)
+	step(`^I open a websocket to "([^"]*)"$`, ctx.IOpenAWebsocketTo)
+	step(`^I send the websocket message "([^"]*)"$`, ctx.ISendTheWebsocketMessage)
+	step(`^I send the websocket message:$`, ctx.ISendTheWebsocketMessageWithBody)
+	step(`^I should receive the websocket message "([^"]*)" within (\d+) seconds$`, ctx.IShouldReceiveTheWebsocketMessageWithin)
+	step(`^I should receive a websocket message matching json within (\d+) seconds:$`, ctx.IShouldReceiveAWebsocketMessageMatchingJSONWithin)
+	step(`^I should receive a websocket message with json path "([^"]*)" with value "([^"]*)" within (\d+) seconds$`, ctx.IShouldReceiveAWebsocketMessageWithJSONPathWithin)
+	step(`^I close the websocket with code (\d+)$`, ctx.ICloseTheWebsocketWithCode)
+	step(`^The mock "([^"]*)" responds to "([^"]*)" with status (\d+) and body:$`, ctx.TheMockRespondsToWithStatusAndBody)
+	step(`^The mock "([^"]*)" responds to "([^"]*)" with status (\d+)$`, ctx.TheMockRespondsToWithStatus)
+	step(`^The response code should be (\d+)$`, ctx.TheResponseCodeShouldBe)
+	step(`^The response "([^"]*)" code should be (\d+)$`, ctx.TheResponseCodeOfShouldBe)
+	step(`^The response "([^"]*)" should match json:$`, ctx.TheResponseOfShouldMatchJSON)
+	step(`^The response "([^"]*)" header "([^"]*)" should have value "([^"]*)"$`, ctx.TheResponseHeaderOfShouldHaveValue)
+	step(`^The json path "([^"]*)" of response "([^"]*)" should have value "([^"]*)"$`, ctx.TheJSONPathOfResponseShouldHaveValue)
+	step(`^The json path "([^"]*)" of response "([^"]*)" should match "([^"]*)"$`, ctx.TheJSONPathOfResponseShouldMatch)
+	step(`^The json path "([^"]*)" of response "([^"]*)" should be present$`, ctx.TheJSONPathOfResponseShouldBePresent)
+	step(`^I store the value of json path "([^"]*)" of response "([^"]*)" as "([^"]*)" in scenario scope$`, ctx.StoreJSONPathValueOfResponse)
+	step(`^The response should be a valid json$`, ctx.TheResponseShouldBeAValidJSON)
+	step(`^The response should match json:$`, ctx.TheResponseShouldMatchJSON)
+	step(`^The response header "([^"]*)" should have value "([^"]*)"$`, ctx.TheResponseHeaderShouldHaveValue)
+	// the unquoted form is kept for the feature files written before the quoted one.
+	step(`^The response header "([^"]*)" should have value ([^"]*)$`, ctx.TheResponseHeaderShouldHaveValue)
+	step(`^The response header "([^"]*)" should have values "([^"]*)"$`, ctx.TheResponseHeaderShouldHaveValues)
+	step(`^The response header "([^"]*)" should be present$`, ctx.TheResponseHeaderShouldBePresent)
+	step(`^The response header "([^"]*)" should not be present$`, ctx.TheResponseHeaderShouldNotBePresent)
+	step(`^The response header "([^"]*)" should match "([^"]*)"$`, ctx.TheResponseHeaderShouldMatch)
+	step(`^The response header "([^"]*)" should contain "([^"]*)"$`, ctx.TheResponseHeaderShouldContain)
+	step(`^The response header "([^"]*)" should be greater than "([^"]*)"$`, ctx.TheResponseHeaderShouldBeGreaterThan)
+	step(`^The response header "([^"]*)" should be less than "([^"]*)"$`, ctx.TheResponseHeaderShouldBeLessThan)
+	step(`^The response header "([^"]*)" should be a date in the (future|past)$`, ctx.TheResponseHeaderShouldBeADateInThe)
+	step(`^The response should have the following headers:$`, ctx.TheResponseShouldHaveTheFollowingHeaders)
+	step(`^The response should have a link with rel "([^"]*)"$`, ctx.TheResponseShouldHaveALinkWithRel)
+	step(`^The response should not have a link with rel "([^"]*)"$`, ctx.TheResponseShouldNotHaveALinkWithRel)
+	step(`^The link with rel "([^"]*)" should have url "([^"]*)"$`, ctx.TheLinkWithRelShouldHaveURL)
+	step(`^The response should allow the origin "([^"]*)"$`, ctx.TheResponseShouldAllowTheOrigin)
+	step(`^The response should not allow the origin "([^"]*)"$`, ctx.TheResponseShouldNotAllowTheOrigin)
+	step(`^The response should allow the method "([^"]*)"$`, ctx.TheResponseShouldAllowTheMethod)
+	step(`^The response should allow the headers "([^"]*)"$`, ctx.TheResponseShouldAllowTheHeaders)
+	step(`^The response should expose the headers "([^"]*)"$`, ctx.TheResponseShouldExposeTheHeaders)
+	step(`^The response should allow credentials$`, ctx.TheResponseShouldAllowCredentials)
+	step(`^The CORS max age should be at least (\d+) seconds$`, ctx.TheCORSMaxAgeShouldBeAtLeastSeconds)
+	step(`^The response should be cacheable for at least (\d+) seconds$`, ctx.TheResponseShouldBeCacheableForAtLeastSeconds)
+	step(`^The response should not be cacheable$`, ctx.TheResponseShouldNotBeCacheable)
+	step(`^The response Cache-Control should include "([^"]*)"$`, ctx.TheResponseCacheControlShouldInclude)
+	step(`^The response Cache-Control should not include "([^"]*)"$`, ctx.TheResponseCacheControlShouldNotInclude)
+	step(`^The response should vary by "([^"]*)"$`, ctx.TheResponseShouldVaryBy)
+	step(`^The response age should be at most (\d+) seconds$`, ctx.TheResponseAgeShouldBeAtMostSeconds)
+	step(`^The response content type should be "([^"]*)"$`, ctx.TheResponseContentTypeShouldBe)
+	step(`^The response should match json schema "([^"]*)"$`, ctx.TheResponseShouldMatchJsonSchema)
+	step(`^The json path "([^"]*)" should have value "([^"]*)"$`, ctx.TheJSONPathShouldHaveValue)
+	step(`^The json path "([^"]*)" should match "([^"]*)"$`, ctx.TheJSONPathShouldMatch)
+	step(`^The json path "([^"]*)" should have count "([^"]*)"$`, ctx.TheJSONPathHaveCount)
+	step(`^The json path "([^"]*)" should be present$`, ctx.TheJSONPathShouldBePresent)
+	step(`^The json path "([^"]*)" should not be present$`, ctx.TheJSONPathShouldNotBePresent)
+	step(`^The json path "([^"]*)" should be null$`, ctx.TheJSONPathShouldBeNull)
+	step(`^The json path "([^"]*)" should be of type "(array|object|string|number|boolean|null)"$`, ctx.TheJSONPathShouldBeOfType)
+	step(`^The json path "([^"]*)" should be empty$`, ctx.TheJSONPathShouldBeEmpty)
+	step(`^The json path "([^"]*)" should contain "([^"]*)"$`, ctx.TheJSONPathShouldContain)
+	step(`^The json path "([^"]*)" should be greater than "([^"]*)"$`, ctx.TheJSONPathShouldBeGreaterThan)
+	step(`^The json path "([^"]*)" should be less than "([^"]*)"$`, ctx.TheJSONPathShouldBeLessThan)
+	step(`^The json path "([^"]*)" should be between "([^"]*)" and "([^"]*)"$`, ctx.TheJSONPathShouldBeBetween)
+	step(`^The json path "([^"]*)" should be one of "([^"]*)"$`, ctx.TheJSONPathShouldBeOneOf)
+	step(`^The json path "([^"]*)" should have value json:$`, ctx.TheJSONPathShouldHaveJSONValue)
+	step(`^The json path "([^"]*)" should have length "(\d+)"$`, ctx.TheJSONPathShouldHaveLength)
+	step(`^Every element of json path "([^"]*)" should have "([^"]*)" (equal to|matching) "([^"]*)"$`, ctx.EveryElementOfJSONPathShouldHave)
+	step(`^At least one element of json path "([^"]*)" should have "([^"]*)" (equal to|matching) "([^"]*)"$`, ctx.AtLeastOneElementOfJSONPathShouldHave)
+	step(`^No element of json path "([^"]*)" should have "([^"]*)" (equal to|matching) "([^"]*)"$`, ctx.NoElementOfJSONPathShouldHave)
+	step(`^The json path "([^"]*)" should be sorted (ascending|descending)$`, ctx.TheJSONPathShouldBeSorted)
+	step(`^The response should have the following json paths:$`, ctx.TheResponseShouldHaveTheFollowingJSONPaths)
+	step(`^The response should be valid xml$`, ctx.TheResponseShouldBeValidXML)
+	step(`^The xpath "([^"]*)" should have value "([^"]*)"$`, ctx.TheXPathShouldHaveValue)
+	step(`^The xpath "([^"]*)" should have count "([^"]*)"$`, ctx.TheXPathShouldHaveCount)
+	step(`^The response should match xsd "([^"]*)"$`, ctx.TheResponseShouldMatchXSD)
+	step(`^The GraphQL response should have no errors$`, ctx.TheGraphQLResponseShouldHaveNoErrors)
+	step(`^The GraphQL error at index (\d+) should have message "([^"]*)"$`, ctx.TheGraphQLErrorAtIndexShouldHaveMessage)
+	step(`^The GraphQL path "([^"]*)" should have value "([^"]*)"$`, ctx.TheGraphQLPathShouldHaveValue)
+	step(`^The GraphQL path "([^"]*)" should match "([^"]*)"$`, ctx.TheGraphQLPathShouldMatch)
+	step(`^The GraphQL path "([^"]*)" should be present$`, ctx.TheGraphQLPathShouldBePresent)
+	step(`^The GraphQL path "([^"]*)" should not be present$`, ctx.TheGraphQLPathShouldNotBePresent)
+	step(`^The GraphQL path "([^"]*)" should have count "([^"]*)"$`, ctx.TheGraphQLPathHaveCount)
+	step(`^The last event data should contain "([^"]*)"$`, ctx.TheLastEventDataShouldContain)
+	step(`^The last event json path "([^"]*)" should have value "([^"]*)"$`, ctx.TheLastEventJSONPathShouldHaveValue)
+	step(`^The last event json path "([^"]*)" should match "([^"]*)"$`, ctx.TheLastEventJSONPathShouldMatch)
+	step(`^The last event json path "([^"]*)" should be present$`, ctx.TheLastEventJSONPathShouldBePresent)
+	step(`^The mock "([^"]*)" should have received (\d+) requests? to "([^"]*)"$`, ctx.TheMockShouldHaveReceivedRequestsTo)
+	step(`^The last request to mock "([^"]*)" should have json path "([^"]*)" with value "([^"]*)"$`, ctx.TheLastRequestToMockShouldHaveJSONPathWithValue)
+	step(`^The response body should contain "([^"]*)"$`, ctx.TheResponseBodyShouldContain)
+	step(`^The response body should match "([^"]*)"$`, ctx.TheResponseBodyShouldMatch)
+	step(`^I wait for (\d+) seconds$`, ctx.WaitForSomeTime)
+	step(`^I store data in scope variable "([^"]*)" with value "([^"]*)"`, ctx.StoreScopeData)
+	step(`^I store the value of response header "([^"]*)" as "([^"]*)" in scenario scope$`, ctx.StoreResponseHeader)
+	step(`^I store the link with rel "([^"]*)" as "([^"]*)" in scenario scope$`, ctx.StoreLinkWithRel)
+	step(`^I store the value of body path "([^"]*)" as "([^"]*)" in scenario scope$`, ctx.StoreJsonPathValue)
+	step(`^I store the value of xpath "([^"]*)" as "([^"]*)" in scenario scope$`, ctx.StoreXPathValue)
+	step(`^I store the value of GraphQL path "([^"]*)" as "([^"]*)" in scenario scope$`, ctx.StoreGraphQLPathValue)
+	step(`^I store the value of last event json path "([^"]*)" as "([^"]*)" in scenario scope$`, ctx.StoreLastEventJSONPathValue)
+	step(`^The scope variable "([^"]*)" should have value "([^"]*)"$`, ctx.TheScopeVariableShouldHaveValue)
 }
 
 // reset Reset the internal state of the API context
-func (ctx *ApiContext) reset(*godog.Scenario) {
-	ctx.headers = make(map[string]string)
-	ctx.queryParams = make(map[string]string)
+func (ctx *ApiContext) reset(sc *godog.Scenario) {
+	ctx.selectProfile(sc)
+	ctx.headers = ctx.defaultHeadersCopy()
+	ctx.scenarioHeaders = map[string]bool{}
+	ctx.removedHeaders = map[string]bool{}
+	ctx.currentService = ""
+	ctx.queryParams = nil
+	ctx.rawQuery = ""
+	ctx.pathParams = make(map[string]string)
 	ctx.lastResponse = nil
 	ctx.lastRequest = nil
+	ctx.cacheValidators = nil
+	ctx.graphQLVariables = nil
+	ctx.closeSSEStream()
+	ctx.lastEvent = nil
+	_ = ctx.closeWebsocket(websocket.CloseNormalClosure)
+	ctx.resetMocks()
+	ctx.startCassette(sc)
+	ctx.exchanges = nil
+	ctx.logBuffer = nil
+	ctx.scenarioName = ""
+	if sc != nil {
+		ctx.scenarioName = sc.Name
+	}
 }
 
 // ISetHeadersTo This step sets the request headers using a datatable as source.
 // It allows to define multiple headers at the same time.
 func (ctx *ApiContext) ISetHeadersTo(dt *godog.Table) error {
 	for i := 0; i < len(dt.Rows); i++ {
-		ctx.headers[dt.Rows[i].Cells[0].Value] = ctx.ReplaceScopeVariables(dt.Rows[i].Cells[1].Value)
+		ctx.headers.Set(dt.Rows[i].Cells[0].Value, ctx.ReplaceScopeVariables(dt.Rows[i].Cells[1].Value))
+		ctx.scenarioHeaders[http.CanonicalHeaderKey(dt.Rows[i].Cells[0].Value)] = true
+		delete(ctx.removedHeaders, http.CanonicalHeaderKey(dt.Rows[i].Cells[0].Value))
 	}
 
 	return nil
@@ -130,20 +344,24 @@ func (ctx *ApiContext) ISetHeadersTo(dt *godog.Table) error {
 
 // ISetHeaderWithValue Step that add a new header to the current request.
 func (ctx *ApiContext) ISetHeaderWithValue(name string, value string) error {
-	ctx.headers[name] = value
+	ctx.headers.Set(name, value)
+	ctx.scenarioHeaders[http.CanonicalHeaderKey(name)] = true
+	delete(ctx.removedHeaders, http.CanonicalHeaderKey(name))
 	return nil
 }
 
-// ISetQueryParamWithValue Adds a new query param to the request
+// ISetQueryParamWithValue Adds a new query param to the request, replacing its previous values.
 func (ctx *ApiContext) ISetQueryParamWithValue(name string, value string) error {
-	ctx.queryParams[name] = ctx.ReplaceScopeVariables(value)
+	ctx.setQueryParam(name, ctx.ReplaceScopeVariables(value))
 	return nil
 }
 
-// ISetQueryParamsTo Set query params from a Data Table
+// ISetQueryParamsTo Set query params from a Data Table. Rows with the same param add values to it.
 func (ctx *ApiContext) ISetQueryParamsTo(dt *godog.Table) error {
 	for i := 0; i < len(dt.Rows); i++ {
-		ctx.queryParams[dt.Rows[i].Cells[0].Value] = ctx.ReplaceScopeVariables(dt.Rows[i].Cells[1].Value)
+		if err := ctx.IAddQueryParamWithValue(dt.Rows[i].Cells[0].Value, dt.Rows[i].Cells[1].Value); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -151,58 +369,18 @@ func (ctx *ApiContext) ISetQueryParamsTo(dt *godog.Table) error {
 
 // ISendRequestTo Sends a request to the specified endpoint using the specified method.
 func (ctx *ApiContext) ISendRequestTo(method, uri string) error {
-	reqURL := fmt.Sprintf("%s%s", ctx.baseURL, uri)
-
-	req, err := http.NewRequest(method, reqURL, nil)
+	req, err := ctx.newRequest(method, uri, nil)
 
 	if err != nil {
 		return err
 	}
 
-	// Add headers to request
-	for name, value := range ctx.headers {
-		req.Header.Set(name, value)
-	}
-
-	// Add query string to request
-	q := req.URL.Query()
-	for name, value := range ctx.queryParams {
-		q.Add(name, value)
-	}
-
-	req.URL.RawQuery = q.Encode()
-
-	ctx.logRequest(req)
-
-	ctx.lastRequest = req
-	resp, err := ctx.client.Do(req)
-
-	if err != nil {
-		return err
-	}
-
-	ctx.logResponse(resp)
-
-	body, err2 := ioutil.ReadAll(resp.Body)
-
-	if err2 != nil {
-		return err2
-	}
-
-	ctx.lastResponse = &ApiResponse{
-		StatusCode:  resp.StatusCode,
-		ResponseObj: resp,
-		Body:        string(body),
-	}
-
-	return nil
+	return ctx.sendRequest(req)
 }
 
 // ISendRequestToWithFormBody Send a request with json body. Ex: a POST request.
 func (ctx *ApiContext) ISendRequestToWithFormBody(method, uri string, requestBodyTable *godog.Table) error {
 
-	reqURL := fmt.Sprintf("%s%s", ctx.baseURL, uri)
-
 	reqBody := &bytes.Buffer{}
 	w := multipart.NewWriter(reqBody)
 
@@ -240,84 +418,96 @@ func (ctx *ApiContext) ISendRequestToWithFormBody(method, uri string, requestBod
 		return err
 	}
 
-	req, err := http.NewRequest(method, reqURL, bytes.NewReader(reqBody.Bytes()))
+	req, err := ctx.newRequest(method, uri, bytes.NewReader(reqBody.Bytes()))
 	if err != nil {
 		return err
 	}
-	req.Header.Set("Content-Type", contentType)
-	for name, value := range ctx.headers {
-		req.Header.Set(name, value)
+	if !ctx.hasHeader("Content-Type") {
+		req.Header.Set("Content-Type", contentType)
 	}
 
-	ctx.logRequest(req)
+	return ctx.sendRequest(req)
+}
 
-	ctx.lastRequest = req
-	resp, err := ctx.client.Do(req)
+// ISendRequestToWithBody Send a request with the DocString as body. Ex: a POST request.
+// The Content-Type is set from the DocString media type, like ```json, or detected from the body.
+func (ctx *ApiContext) ISendRequestToWithBody(method, uri string, requestBody *godog.DocString) error {
+	req, err := ctx.newBodyRequest(method, uri, requestBody)
 
 	if err != nil {
 		return err
 	}
 
-	ctx.logResponse(resp)
+	return ctx.sendRequest(req)
+}
 
-	body, err2 := ioutil.ReadAll(resp.Body)
+// newBodyRequest creates a request with the DocString as body and its Content-Type.
+func (ctx *ApiContext) newBodyRequest(method, uri string, requestBody *godog.DocString) (*http.Request, error) {
+	body := []byte(ctx.ReplaceScopeVariables(requestBody.Content))
+	req, err := ctx.newRequest(method, uri, bytes.NewBuffer(body))
 
-	if err2 != nil {
-		return err2
+	if err != nil {
+		return nil, err
 	}
 
-	ctx.lastResponse = &ApiResponse{
-		StatusCode:  resp.StatusCode,
-		ResponseObj: resp,
-		Body:        string(body),
-	}
+	ctx.setBodyContentType(req, ctx.bodyContentType(requestBody, body))
 
-	return nil
+	return req, nil
 }
 
-// ISendRequestToWithBody Send a request with json body. Ex: a POST request.
-func (ctx *ApiContext) ISendRequestToWithBody(method, uri string, requestBody *godog.DocString) error {
-
-	reqURL := fmt.Sprintf("%s%s", ctx.baseURL, uri)
-	jsonBody := ctx.ReplaceScopeVariables(requestBody.Content)
-	//todo
-	var jsonBodyBytes = []byte(jsonBody)
-	req, err := http.NewRequest(method, reqURL, bytes.NewBuffer(jsonBodyBytes))
-
-	for name, value := range ctx.headers {
-		req.Header.Set(name, value)
-	}
-
-	if err != nil {
-		return err
+// sendRequest Sends the request using the context client and stores the response as the last response.
+func (ctx *ApiContext) sendRequest(req *http.Request) error {
+	if ctx.profileErr != nil {
+		return ctx.profileErr
 	}
 
 	ctx.logRequest(req)
 
 	ctx.lastRequest = req
+	reqBody := requestBody(req)
+	startedAt := time.Now()
 	resp, err := ctx.client.Do(req)
 
 	if err != nil {
+		ctx.recordExchange(req, reqBody, nil, startedAt)
 		return err
 	}
 
-	ctx.logResponse(resp)
+	defer resp.Body.Close()
 
-	body, err2 := ioutil.ReadAll(resp.Body)
+	body, err := ioutil.ReadAll(resp.Body)
 
-	if err2 != nil {
-		return err2
+	if err != nil {
+		ctx.recordExchange(req, reqBody, nil, startedAt)
+		return err
 	}
 
+	ctx.logResponse(resp, body, time.Since(startedAt))
+
 	ctx.lastResponse = &ApiResponse{
 		StatusCode:  resp.StatusCode,
 		ResponseObj: resp,
 		Body:        string(body),
+		decoders:    ctx.bodyDecoders,
 	}
+	ctx.recordExchange(req, reqBody, ctx.lastResponse, startedAt)
 
 	return nil
 }
 
+// LastResponse Returns the response of the last request sent in the scenario, or nil if no request was sent.
+func (ctx *ApiContext) LastResponse() *ApiResponse {
+	return ctx.lastResponse
+}
+
+// SetLastResponse Replaces the last response, so the response steps can check responses obtained by other means, like gRPC calls.
+// The body is decoded with the decoders of the context.
+func (ctx *ApiContext) SetLastResponse(resp *ApiResponse) {
+	resp.decoders = ctx.bodyDecoders
+	resp.hasDecoded = false
+	ctx.lastResponse = resp
+}
+
 // TheResponseCodeShouldBe Check if the http status code of the response matches the specified value.
 func (ctx *ApiContext) TheResponseCodeShouldBe(statusCode int) error {
 	if statusCode != ctx.lastResponse.StatusCode {
@@ -328,58 +518,27 @@ func (ctx *ApiContext) TheResponseCodeShouldBe(statusCode int) error {
 
 // TheResponseShouldBeAValidJSON checks if the response is a valid JSON.
 func (ctx *ApiContext) TheResponseShouldBeAValidJSON() error {
-	var data interface{}
-	return json.Unmarshal([]byte(ctx.lastResponse.Body), &data)
+	_, err := ctx.lastResponse.decodedJSON()
+	return err
 }
 
 // TheJSONPathShouldHaveValue Validates if the json object have the expected value at the specified path.
 func (ctx *ApiContext) TheJSONPathShouldHaveValue(pathExpr string, expectedValue string) error {
-	var jsonData interface{}
-	expectedValue = ctx.ReplaceScopeVariables(expectedValue)
-	if err := json.Unmarshal([]byte(ctx.lastResponse.Body), &jsonData); err != nil {
-		return err
-	}
-
-	actualValue, err := jsonpath.Get(pathExpr, jsonData)
+	actualValue, err := ctx.jsonPathValue(pathExpr)
 
 	if err != nil {
 		return err
 	}
 
-	var expectedParsedValue interface{}
-	switch reflect.TypeOf(actualValue).Kind() {
-	case reflect.Bool:
-		expectedParsedValue, err = strconv.ParseBool(expectedValue)
-
-		if err != nil {
-			return err
-		}
-
-	case reflect.Float64:
-		expectedParsedValue, err = strconv.ParseFloat(expectedValue, 64)
-
-		if err != nil {
-			return err
-		}
-	case reflect.Int32:
-		expectedParsedValue, err = strconv.ParseInt(expectedValue, 10, 64)
-
-		if err != nil {
-			return err
-		}
-	case reflect.Int64:
-		expectedParsedValue, err = strconv.ParseInt(expectedValue, 10, 64)
-
-		if err != nil {
-			return err
-		}
+	expectedValue = ctx.ReplaceScopeVariables(expectedValue)
+	match, err := jsonValueEquals(actualValue, expectedValue)
 
-	default:
-		expectedParsedValue = expectedValue
+	if err != nil {
+		return err
 	}
 
-	if actualValue != expectedParsedValue {
-		return fmt.Errorf("expected json path to have value %v but it is %v", expectedParsedValue, actualValue)
+	if !match {
+		return jsonPathError(pathExpr, fmt.Sprintf("to have value %s", expectedValue), actualValue)
 	}
 
 	return nil
@@ -387,26 +546,20 @@ func (ctx *ApiContext) TheJSONPathShouldHaveValue(pathExpr string, expectedValue
 
 // TheJSONPathShouldMatch Validates Checks if the the value from the specified json path matches the specified pattern.
 func (ctx *ApiContext) TheJSONPathShouldMatch(pathExpr string, pattern string) error {
-	var jsonData interface{}
-
-	if err := json.Unmarshal([]byte(ctx.lastResponse.Body), &jsonData); err != nil {
-		return err
-	}
-
-	value, err := jsonpath.Get(pathExpr, jsonData)
+	value, err := ctx.jsonPathValue(pathExpr)
 
 	if err != nil {
 		return err
 	}
 
-	match, err := regexp.MatchString(pattern, value.(string))
+	match, err := regexp.MatchString(pattern, jsonValueToString(value))
 
 	if err != nil {
 		return err
 	}
 
 	if !match {
-		return fmt.Errorf("%s does not match: %s", value, pattern)
+		return jsonPathError(pathExpr, fmt.Sprintf("to match %s", pattern), value)
 	}
 
 	return nil
@@ -414,48 +567,27 @@ func (ctx *ApiContext) TheJSONPathShouldMatch(pathExpr string, pattern string) e
 
 // TheJSONPathShouldBePresent checks if the specified json path exists in the response body
 func (ctx *ApiContext) TheJSONPathShouldBePresent(pathExpr string) error {
-	var jsonData interface{}
-
-	if err := json.Unmarshal([]byte(ctx.lastResponse.Body), &jsonData); err != nil {
-		return err
-	}
-
-	value, err := jsonpath.Get(pathExpr, jsonData)
-
-	if err != nil {
-		return err
-	}
-
-	if value == nil {
-		return fmt.Errorf("the json path %s was not present in the response", pathExpr)
-	}
+	_, err := ctx.jsonPathValue(pathExpr)
 
-	return nil
+	return err
 }
 
 // TheJSONPathHaveCount Validates if the field at the specified json path have the expected length
 func (ctx *ApiContext) TheJSONPathHaveCount(pathExpr string, expectedCount int) error {
-	var jsonData interface{}
-
-	if err := json.Unmarshal([]byte(ctx.lastResponse.Body), &jsonData); err != nil {
-		return err
-
-	}
-
-	value, err := jsonpath.Get(pathExpr, jsonData)
+	value, err := ctx.jsonPathValue(pathExpr)
 
 	if err != nil {
 		return err
 	}
 
-	s := reflect.ValueOf(value)
+	s, ok := value.([]interface{})
 
-	if s.Kind() != reflect.Slice {
-		return fmt.Errorf("the json path %s is not an array. Found %v", pathExpr, value)
+	if !ok {
+		return jsonPathError(pathExpr, "to be an array", value)
 	}
 
-	if s.Len() != expectedCount {
-		return fmt.Errorf("the value %v doenst have count %d but %d", value, expectedCount, s.Len())
+	if len(s) != expectedCount {
+		return jsonPathError(pathExpr, fmt.Sprintf("to have count %d", expectedCount), value)
 	}
 
 	return nil
@@ -467,11 +599,17 @@ func (ctx *ApiContext) TheResponseShouldMatchJSON(body *godog.DocString) error {
 
 	expected := body.Content
 
-	match, err := isEqualJson(actual, expected)
+	actualData, err := ctx.lastResponse.decodedJSON()
 	if err != nil {
 		return err
 	}
-	if !match {
+
+	var expectedData interface{}
+	if err := json.Unmarshal([]byte(expected), &expectedData); err != nil {
+		return err
+	}
+
+	if !reflect.DeepEqual(actualData, expectedData) {
 		return fmt.Errorf("expected json %s, does not match actual: %s", expected, actual)
 	}
 	return nil
@@ -520,8 +658,13 @@ func (ctx *ApiContext) TheResponseShouldMatchJsonSchema(path string) error {
 		return fmt.Errorf("cannot open json schema file: %s", err)
 	}
 
+	document, err := ctx.lastResponse.decodedJSON()
+	if err != nil {
+		return err
+	}
+
 	schemaLoader := gojsonschema.NewBytesLoader(schemaContents)
-	documentLoader := gojsonschema.NewStringLoader(ctx.lastResponse.Body)
+	documentLoader := gojsonschema.NewGoLoader(document)
 	result, err := gojsonschema.Validate(schemaLoader, documentLoader)
 
 	if err != nil {
@@ -542,33 +685,19 @@ func (ctx *ApiContext) TheResponseShouldMatchJsonSchema(path string) error {
 
 // TheResponseHeaderShouldHaveValue Verify the value of a response header
 func (ctx *ApiContext) TheResponseHeaderShouldHaveValue(name string, expectedValue string) error {
-	actualValue := ctx.lastResponse.ResponseObj.Header.Get(name)
-
-	if actualValue != ctx.ReplaceScopeVariables(expectedValue) {
-		return fmt.Errorf("expected header to have value %s. actual : %s", expectedValue, actualValue)
-	}
-
-	return nil
-}
-
-// logRequest Helper function to log the request
-func (ctx *ApiContext) logRequest(request *http.Request) {
-	if !ctx.debug {
-		return
+	header, err := ctx.responseHeader()
+	if err != nil {
+		return err
 	}
 
-	dump, _ := httputil.DumpRequestOut(request, true)
-	log.Println(string(dump))
-}
+	actualValue := header.Get(name)
+	expectedValue = ctx.ReplaceScopeVariables(expectedValue)
 
-// // logResponse Helper function to log the response
-func (ctx *ApiContext) logResponse(response *http.Response) {
-	if !ctx.debug {
-		return
+	if actualValue != expectedValue {
+		return fmt.Errorf("expected header %s to have value %s. actual : %s", name, expectedValue, actualValue)
 	}
 
-	dump, _ := httputil.DumpResponse(response, true)
-	log.Println(string(dump))
+	return nil
 }
 
 // WaitForSomeTime halt for some time.
@@ -586,25 +715,23 @@ func (ctx *ApiContext) StoreScopeData(scopeKeyName string, value string) error {
 
 // StoreResponseHeader Store header value to scope map.
 func (ctx *ApiContext) StoreResponseHeader(name string, scopeKeyName string) error {
-	actualValue := ctx.lastResponse.ResponseObj.Header.Get(name)
-	ctx.scope[scopeKeyName] = actualValue
+	header, err := ctx.responseHeader()
+	if err != nil {
+		return err
+	}
+
+	ctx.scope[scopeKeyName] = header.Get(name)
 	return nil
 }
 
 // StoreJsonPathValue Store value from json body path to scope map.
 func (ctx *ApiContext) StoreJsonPathValue(pathExpr string, scopeKeyName string) error {
-	var jsonData interface{}
-
-	if err := json.Unmarshal([]byte(ctx.lastResponse.Body), &jsonData); err != nil {
-		return err
-	}
-
-	actualValue, err := jsonpath.Get(pathExpr, jsonData)
+	actualValue, err := ctx.jsonPathValue(pathExpr)
 
 	if err != nil {
 		return err
 	}
-	ctx.scope[scopeKeyName] = actualValue.(string)
+	ctx.scope[scopeKeyName] = jsonValueToString(actualValue)
 	return nil
 }
 
@@ -21,12 +21,44 @@ func setupTestContext() *ApiContext {
 
 	return ctx
 }
+
+// setupResponseTestContext sends a request to a test server that responds with the content type and body,
+// and returns the context with the response and the function that stops the server.
+func setupResponseTestContext(t *testing.T, contentType string, body string) (*ApiContext, func()) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write([]byte(body))
+	}))
+
+	ctx := New(ts.URL).
+		WithJSONSchemasPath("testdata/schemas").
+		WithXMLSchemasPath("testdata/schemas")
+
+	if err := ctx.ISendRequestTo("GET", "/"); err != nil {
+		ts.Close()
+		t.Fatal(err)
+	}
+
+	return ctx, ts.Close
+}
+
+// readTestData returns the content of a file of the testdata directory.
+func readTestData(t *testing.T, name string) string {
+	f, err := ioutil.ReadFile(filepath.Join("testdata", name))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(f)
+}
+
 func TestApiContext_New(t *testing.T) {
 
 	ctx := setupTestContext()
 
 	assert.Equal(t, "https://example.com", ctx.baseURL)
-	assert.True(t, ctx.debug)
+	assert.Equal(t, LogLevelDebug, ctx.logLevel)
 	assert.Equal(t, "testdata/schemas", ctx.jSONSchemasPath)
 }
 
@@ -62,8 +94,8 @@ func TestApiContext_ISetHeadersTo(t *testing.T) {
 	err := ctx.ISetHeadersTo(dt)
 
 	assert.Nil(t, err)
-	assert.Equal(t, "value 1", ctx.headers["X-Header-1"])
-	assert.Equal(t, "value 2", ctx.headers["X-Header-2"])
+	assert.Equal(t, "value 1", ctx.headers.Get("X-Header-1"))
+	assert.Equal(t, "value 2", ctx.headers.Get("X-Header-2"))
 }
 
 func TestApiContext_ISetHeaderWithValue(t *testing.T) {
@@ -72,7 +104,7 @@ func TestApiContext_ISetHeaderWithValue(t *testing.T) {
 
 	assert.Nil(t, err)
 	assert.Equal(t, 1, len(ctx.headers))
-	assert.Equal(t, "application/json", ctx.headers["Content-Type"])
+	assert.Equal(t, "application/json", ctx.headers.Get("Content-Type"))
 }
 
 func TestApiContext_ISetQueryParamWithValue(t *testing.T) {
@@ -82,7 +114,7 @@ func TestApiContext_ISetQueryParamWithValue(t *testing.T) {
 
 	assert.Nil(t, err)
 	assert.Equal(t, 1, len(ctx.queryParams))
-	assert.Equal(t, "1", ctx.queryParams["page"])
+	assert.Equal(t, []string{"1"}, ctx.queryParamValues("page"))
 }
 
 func TestApiContext_ISetQueryParamsTo(t *testing.T) {
@@ -117,8 +149,8 @@ func TestApiContext_ISetQueryParamsTo(t *testing.T) {
 	err := ctx.ISetQueryParamsTo(dt)
 
 	assert.Nil(t, err)
-	assert.Equal(t, "v1", ctx.queryParams["q1"])
-	assert.Equal(t, "v2", ctx.queryParams["q2"])
+	assert.Equal(t, []string{"v1"}, ctx.queryParamValues("q1"))
+	assert.Equal(t, []string{"v2"}, ctx.queryParamValues("q2"))
 }
 
 func TestApiContext_ISendRequestTo(t *testing.T) {
@@ -156,6 +188,26 @@ func TestApiContext_ISendRequestTo(t *testing.T) {
 	}))
 }
 
+func TestApiContext_TheResponseShouldMatchJSON(t *testing.T) {
+	ctx := setupTestContext()
+	ctx.lastResponse = &ApiResponse{Body: `{"result": "success"}`}
+
+	assert.Nil(t, ctx.TheResponseShouldMatchJSON(&godog.DocString{Content: `{"result": "success"}`}))
+	assert.NotNil(t, ctx.TheResponseShouldMatchJSON(&godog.DocString{Content: `{"result": "failure"}`}))
+	assert.NotNil(t, ctx.TheResponseShouldMatchJSON(&godog.DocString{Content: `{"result": "success", "id": 1}`}))
+	assert.NotNil(t, ctx.TheResponseShouldMatchJSON(&godog.DocString{Content: `not json`}))
+}
+
+func TestIsEqualJson(t *testing.T) {
+	match, err := isEqualJson(`{"a": 1, "b": [1, 2]}`, `{"b": [1, 2], "a": 1}`)
+	assert.Nil(t, err)
+	assert.True(t, match)
+
+	match, err = isEqualJson(`{"a": 1}`, `{"a": 2}`)
+	assert.Nil(t, err)
+	assert.False(t, match)
+}
+
 func TestApiContext_ISendRequestToWithFormBody(t *testing.T) {
 	value := "world"
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -459,11 +511,11 @@ func TestReset(t *testing.T) {
 	ctx := setupTestContext()
 
 	p := &messages.Pickle{}
-	ctx.headers = map[string]string{
-		"Content-Type": "application/json",
+	ctx.headers = http.Header{
+		"Content-Type": {"application/json"},
 	}
-	ctx.queryParams = map[string]string{
-		"param": "test",
+	ctx.queryParams = []queryParam{
+		{name: "param", value: "test"},
 	}
 
 	ctx.reset(p)
@@ -541,3 +593,19 @@ func TestApiContext_ReplaceScopeVariables(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, newData, "hello world good")
 }
+
+func TestApiContext_SetLastResponse(t *testing.T) {
+	ctx := setupTestContext()
+	assert.Nil(t, ctx.LastResponse())
+
+	resp := &ApiResponse{
+		StatusCode:  201,
+		Body:        "{\"id\": 1}",
+		ResponseObj: &http.Response{Header: http.Header{"Content-Type": []string{"application/json"}}},
+	}
+	ctx.SetLastResponse(resp)
+
+	assert.Equal(t, resp, ctx.LastResponse())
+	assert.Nil(t, ctx.TheResponseCodeShouldBe(201))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.id", "1"))
+}
@@ -0,0 +1,230 @@
+package apicontext
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/url"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// BodyDecoder decodes a response body into maps, slices and scalar values that can be queried with the json path steps.
+// Numbers must be decoded as float64, like encoding/json does.
+type BodyDecoder interface {
+	Decode(body []byte) (interface{}, error)
+}
+
+// BodyDecoderFunc is an adapter to allow the use of ordinary functions as a BodyDecoder.
+type BodyDecoderFunc func(body []byte) (interface{}, error)
+
+// Decode calls f(body).
+func (f BodyDecoderFunc) Decode(body []byte) (interface{}, error) {
+	return f(body)
+}
+
+// The body decoders registered by default, by media type.
+var (
+	// JSONDecoder decodes JSON documents. It's also used for responses with an unknown content type.
+	JSONDecoder = BodyDecoderFunc(decodeJSON)
+
+	// YAMLDecoder decodes YAML documents.
+	YAMLDecoder = BodyDecoderFunc(decodeYAML)
+
+	// CSVDecoder decodes CSV documents into an array of objects, using the first row as the keys of the objects.
+	CSVDecoder = BodyDecoderFunc(decodeCSV)
+
+	// NDJSONDecoder decodes newline delimited JSON into an array with one element per line.
+	NDJSONDecoder = BodyDecoderFunc(decodeNDJSON)
+
+	// FormDecoder decodes form encoded bodies into an object. Fields with multiple values are decoded as arrays.
+	FormDecoder = BodyDecoderFunc(decodeForm)
+
+	// TextDecoder decodes plain text into a string. It's used for the text types without a registered decoder.
+	// Bodies that are valid json, which many servers send as text/plain, are decoded as json.
+	TextDecoder = BodyDecoderFunc(decodeText)
+)
+
+// WithBodyDecoder Registers the decoder used for responses with the specified media type, like "application/vnd.api+json".
+func (ctx *ApiContext) WithBodyDecoder(mediaType string, decoder BodyDecoder) *ApiContext {
+	ctx.bodyDecoders[strings.ToLower(mediaType)] = decoder
+	return ctx
+}
+
+// defaultBodyDecoders returns the decoders registered by default in the context.
+func defaultBodyDecoders() map[string]BodyDecoder {
+	return map[string]BodyDecoder{
+		"application/json":                  JSONDecoder,
+		"application/x-yaml":                YAMLDecoder,
+		"application/yaml":                  YAMLDecoder,
+		"text/yaml":                         YAMLDecoder,
+		"text/x-yaml":                       YAMLDecoder,
+		"text/csv":                          CSVDecoder,
+		"application/x-ndjson":              NDJSONDecoder,
+		"application/jsonl":                 NDJSONDecoder,
+		"application/x-www-form-urlencoded": FormDecoder,
+	}
+}
+
+// Decoded returns the response body decoded according to its content type.
+// The body is only decoded once, so subsequent calls return the same value.
+func (r *ApiResponse) Decoded() (interface{}, error) {
+	if !r.hasDecoded {
+		decoder, _ := r.decoder()
+		r.decoded, r.decodeErr = decoder.Decode([]byte(r.Body))
+		r.hasDecoded = true
+	}
+
+	return r.decoded, r.decodeErr
+}
+
+// decodedJSON returns the response body decoded as json. The decoded body is reused when the response is a json document,
+// and other responses, like plain text, are parsed as json without changing the decoded body.
+func (r *ApiResponse) decodedJSON() (interface{}, error) {
+	if _, isJSON := r.decoder(); isJSON {
+		return r.Decoded()
+	}
+
+	return decodeJSON([]byte(r.Body))
+}
+
+// decoder returns the decoder registered for the response content type, and if the response is a json document.
+// Text types without a registered decoder are decoded as plain text, and the other types as json.
+func (r *ApiResponse) decoder() (BodyDecoder, bool) {
+	var mediaType string
+	if r.ResponseObj != nil {
+		mediaType, _, _ = mime.ParseMediaType(r.ResponseObj.Header.Get("Content-Type"))
+	}
+
+	if decoder, ok := r.decoders[mediaType]; ok {
+		return decoder, mediaType == "application/json"
+	}
+
+	switch {
+	case strings.HasSuffix(mediaType, "+yaml"):
+		return YAMLDecoder, false
+	case strings.HasPrefix(mediaType, "text/"):
+		return TextDecoder, false
+	default:
+		return JSONDecoder, true
+	}
+}
+
+func decodeJSON(body []byte) (interface{}, error) {
+	var data interface{}
+
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("the response is not a valid json: %v", err)
+	}
+
+	return data, nil
+}
+
+func decodeYAML(body []byte) (interface{}, error) {
+	var data interface{}
+
+	if err := yaml.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("the response is not a valid yaml: %v", err)
+	}
+
+	// re-encode as json so numbers and maps have the same types as decoded json documents.
+	jsonData, err := json.Marshal(data)
+
+	if err != nil {
+		return nil, fmt.Errorf("the response cannot be represented as json: %v", err)
+	}
+
+	return decodeJSON(jsonData)
+}
+
+func decodeCSV(body []byte) (interface{}, error) {
+	records, err := csv.NewReader(bytes.NewReader(body)).ReadAll()
+
+	if err != nil {
+		return nil, fmt.Errorf("the response is not a valid csv: %v", err)
+	}
+
+	rows := make([]interface{}, 0)
+
+	if len(records) == 0 {
+		return rows, nil
+	}
+
+	header := records[0]
+	for _, record := range records[1:] {
+		row := make(map[string]interface{}, len(header))
+		for i, name := range header {
+			if i < len(record) {
+				row[name] = record[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func decodeNDJSON(body []byte) (interface{}, error) {
+	lines := make([]interface{}, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(body)+1)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" {
+			continue
+		}
+
+		var data interface{}
+		if err := json.Unmarshal([]byte(line), &data); err != nil {
+			return nil, fmt.Errorf("the response line %d is not a valid json: %v", n, err)
+		}
+
+		lines = append(lines, data)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+func decodeText(body []byte) (interface{}, error) {
+	var data interface{}
+
+	if err := json.Unmarshal(body, &data); err == nil {
+		return data, nil
+	}
+
+	return string(body), nil
+}
+
+func decodeForm(body []byte) (interface{}, error) {
+	values, err := url.ParseQuery(string(body))
+
+	if err != nil {
+		return nil, fmt.Errorf("the response is not a valid form encoded body: %v", err)
+	}
+
+	data := make(map[string]interface{}, len(values))
+	for name, fieldValues := range values {
+		if len(fieldValues) == 1 {
+			data[name] = fieldValues[0]
+			continue
+		}
+
+		items := make([]interface{}, len(fieldValues))
+		for i, v := range fieldValues {
+			items[i] = v
+		}
+		data[name] = items
+	}
+
+	return data, nil
+}
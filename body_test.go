@@ -0,0 +1,111 @@
+package apicontext
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiResponse_DecodedYAML(t *testing.T) {
+	ctx, teardown := setupResponseTestContext(t, "application/x-yaml", "name: Bruno\nage: 30\ntags:\n  - a\n  - b\n")
+	defer teardown()
+
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.name", "Bruno"))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.age", "30"))
+	assert.Nil(t, ctx.TheJSONPathShouldBeOfType("$.age", "number"))
+	assert.Nil(t, ctx.TheJSONPathHaveCount("$.tags", 2))
+}
+
+func TestApiResponse_DecodedCSV(t *testing.T) {
+	ctx, teardown := setupResponseTestContext(t, "text/csv; charset=utf-8", "id,name\n1,Bruno\n2,Paz\n")
+	defer teardown()
+
+	assert.Nil(t, ctx.TheJSONPathHaveCount("$", 2))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$[1].name", "Paz"))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$[0].id", "1"))
+}
+
+func TestApiResponse_DecodedNDJSON(t *testing.T) {
+	ctx, teardown := setupResponseTestContext(t, "application/x-ndjson", "{\"id\": 1}\n\n{\"id\": 2}\n")
+	defer teardown()
+
+	assert.Nil(t, ctx.TheJSONPathHaveCount("$", 2))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$[1].id", "2"))
+}
+
+func TestApiResponse_DecodedForm(t *testing.T) {
+	ctx, teardown := setupResponseTestContext(t, "application/x-www-form-urlencoded", "name=Bruno&tag=a&tag=b")
+	defer teardown()
+
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.name", "Bruno"))
+	assert.Nil(t, ctx.TheJSONPathHaveCount("$.tag", 2))
+}
+
+func TestApiResponse_DecodedUnknownContentTypeFallsBackToJSON(t *testing.T) {
+	ctx, teardown := setupResponseTestContext(t, "application/vnd.api+json", "{\"data\": {\"id\": \"1\"}}")
+	defer teardown()
+
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.data.id", "1"))
+}
+
+func TestApiResponse_DecodedText(t *testing.T) {
+	ctx, teardown := setupResponseTestContext(t, "text/html; charset=utf-8", "<p>hello</p>")
+	defer teardown()
+
+	decoded, err := ctx.lastResponse.Decoded()
+	assert.Nil(t, err)
+	assert.Equal(t, "<p>hello</p>", decoded)
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$", "<p>hello</p>"))
+	assert.EqualError(t, ctx.TheResponseShouldBeAValidJSON(), "the response is not a valid json: invalid character '<' looking for beginning of value")
+
+	ctx, teardown = setupResponseTestContext(t, "text/plain", `{"name": "Bruno"}`)
+	defer teardown()
+
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.name", "Bruno"))
+	assert.Nil(t, ctx.TheResponseShouldBeAValidJSON())
+	assert.Nil(t, ctx.TheResponseShouldMatchJSON(&godog.DocString{Content: `{"name": "Bruno"}`}))
+}
+
+func TestApiResponse_JSONStepsUseTheDecodedBody(t *testing.T) {
+	calls := 0
+	decoder := BodyDecoderFunc(func(body []byte) (interface{}, error) {
+		calls++
+		return decodeJSON(body)
+	})
+
+	ctx, teardown := setupResponseTestContext(t, "application/json", `{"name": "Bruno", "age": 30}`)
+	defer teardown()
+	ctx.lastResponse.decoders = map[string]BodyDecoder{"application/json": decoder}
+
+	assert.Nil(t, ctx.TheResponseShouldBeAValidJSON())
+	assert.Nil(t, ctx.TheResponseShouldMatchJSON(&godog.DocString{Content: `{"age": 30, "name": "Bruno"}`}))
+	assert.Nil(t, ctx.TheResponseShouldMatchJsonSchema("person.json"))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.name", "Bruno"))
+	assert.Equal(t, 1, calls)
+}
+
+func TestApiResponse_DecodedOnlyOnce(t *testing.T) {
+	calls := 0
+	decoder := BodyDecoderFunc(func(body []byte) (interface{}, error) {
+		calls++
+		return map[string]interface{}{"body": string(body)}, nil
+	})
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer ts.Close()
+
+	ctx := setupTestContext().
+		WithBaseURL(ts.URL).
+		WithBodyDecoder("text/plain", decoder)
+
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.body", "hello"))
+	assert.Nil(t, ctx.TheJSONPathShouldBePresent("$.body"))
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,334 @@
+package apicontext
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cucumber/godog"
+)
+
+// cacheValidators are the ETag and Last-Modified of a response, sent in the conditional requests.
+type cacheValidators struct {
+	etag         string
+	lastModified string
+}
+
+// IStoreTheCacheValidatorsOfTheResponse Stores the ETag and Last-Modified headers of the last response for the conditional requests.
+func (ctx *ApiContext) IStoreTheCacheValidatorsOfTheResponse() error {
+	validators, err := ctx.responseCacheValidators()
+
+	if err != nil {
+		return err
+	}
+
+	ctx.cacheValidators = &validators
+
+	return nil
+}
+
+// ISendAConditionalTo Sends a conditional request with the stored cache validators, or the ones of the last response.
+// GET and HEAD requests send If-None-Match and If-Modified-Since, other methods If-Match and If-Unmodified-Since.
+func (ctx *ApiContext) ISendAConditionalTo(method string, uri string) error {
+	req, err := ctx.newRequest(method, uri, nil)
+
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.setConditionalHeaders(req); err != nil {
+		return err
+	}
+
+	return ctx.sendRequest(req)
+}
+
+// ISendAConditionalToWithBody Sends a conditional request with the DocString as body, like a PUT that must not overwrite changes.
+func (ctx *ApiContext) ISendAConditionalToWithBody(method string, uri string, requestBody *godog.DocString) error {
+	req, err := ctx.newBodyRequest(method, uri, requestBody)
+
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.setConditionalHeaders(req); err != nil {
+		return err
+	}
+
+	return ctx.sendRequest(req)
+}
+
+// TheResponseShouldBeCacheableForAtLeastSeconds Checks that caches can reuse the response for the seconds.
+// The freshness is calculated from the Cache-Control max-age, or the Expires header, minus the Age header.
+func (ctx *ApiContext) TheResponseShouldBeCacheableForAtLeastSeconds(seconds int) error {
+	header, err := ctx.responseHeader()
+
+	if err != nil {
+		return err
+	}
+
+	freshness, err := responseFreshness(header)
+
+	if err != nil {
+		return err
+	}
+
+	if freshness < time.Duration(seconds)*time.Second {
+		return fmt.Errorf("expected the response to be cacheable for at least %d seconds, but it is fresh for %d seconds. %s",
+			seconds, int(freshness.Seconds()), cacheHeadersDescription(header))
+	}
+
+	return nil
+}
+
+// TheResponseShouldNotBeCacheable Checks that caches can't reuse the response without revalidating it.
+func (ctx *ApiContext) TheResponseShouldNotBeCacheable() error {
+	header, err := ctx.responseHeader()
+
+	if err != nil {
+		return err
+	}
+
+	freshness, err := responseFreshness(header)
+
+	if err == nil && freshness > 0 {
+		return fmt.Errorf("expected the response not to be cacheable, but it is fresh for %d seconds. %s",
+			int(freshness.Seconds()), cacheHeadersDescription(header))
+	}
+
+	return nil
+}
+
+// TheResponseCacheControlShouldInclude Checks that the Cache-Control response header has the directive, like "private" or "max-age=60".
+func (ctx *ApiContext) TheResponseCacheControlShouldInclude(directive string) error {
+	header, err := ctx.responseHeader()
+
+	if err != nil {
+		return err
+	}
+
+	if !hasCacheControlDirective(header, directive) {
+		return fmt.Errorf("expected Cache-Control to include %s, but it is %q", directive, header.Get("Cache-Control"))
+	}
+
+	return nil
+}
+
+// TheResponseCacheControlShouldNotInclude Checks that the Cache-Control response header doesn't have the directive, like "no-store".
+func (ctx *ApiContext) TheResponseCacheControlShouldNotInclude(directive string) error {
+	header, err := ctx.responseHeader()
+
+	if err != nil {
+		return err
+	}
+
+	if hasCacheControlDirective(header, directive) {
+		return fmt.Errorf("expected Cache-Control not to include %s, but it is %q", directive, header.Get("Cache-Control"))
+	}
+
+	return nil
+}
+
+// TheResponseShouldVaryBy Checks that the Vary response header lists the comma separated request headers, like "Accept-Encoding".
+func (ctx *ApiContext) TheResponseShouldVaryBy(headers string) error {
+	values, _, err := ctx.responseHeaderValues("Vary")
+
+	if err != nil {
+		return err
+	}
+
+	vary := splitHeaderValues(values)
+
+	if missing := missingHeaderNames(splitHeaderValues([]string{headers}), vary, true); len(missing) > 0 {
+		return fmt.Errorf("expected the response to vary by %s, but Vary is %q", strings.Join(missing, ", "), strings.Join(vary, ", "))
+	}
+
+	return nil
+}
+
+// TheResponseAgeShouldBeAtMostSeconds Checks the Age response header, which caches set to the seconds the response was stored.
+func (ctx *ApiContext) TheResponseAgeShouldBeAtMostSeconds(seconds int) error {
+	header, err := ctx.responseHeader()
+
+	if err != nil {
+		return err
+	}
+
+	age, err := responseAge(header)
+
+	if err != nil {
+		return err
+	}
+
+	if age > seconds {
+		return fmt.Errorf("expected the response age to be at most %d seconds, but it is %d", seconds, age)
+	}
+
+	return nil
+}
+
+// responseCacheValidators returns the ETag and Last-Modified of the last response.
+func (ctx *ApiContext) responseCacheValidators() (cacheValidators, error) {
+	header, err := ctx.responseHeader()
+
+	if err != nil {
+		return cacheValidators{}, err
+	}
+
+	validators := cacheValidators{etag: header.Get("ETag"), lastModified: header.Get("Last-Modified")}
+
+	if validators.etag == "" && validators.lastModified == "" {
+		return cacheValidators{}, fmt.Errorf("expected the response to have an ETag or Last-Modified header")
+	}
+
+	return validators, nil
+}
+
+// setConditionalHeaders sets the conditional headers of the request from the stored cache validators, or the ones of the last response.
+func (ctx *ApiContext) setConditionalHeaders(req *http.Request) error {
+	validators := ctx.cacheValidators
+
+	if validators == nil {
+		v, err := ctx.responseCacheValidators()
+
+		if err != nil {
+			return err
+		}
+
+		validators = &v
+	}
+
+	matchHeader, dateHeader := "If-Match", "If-Unmodified-Since"
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		matchHeader, dateHeader = "If-None-Match", "If-Modified-Since"
+	}
+
+	if validators.etag != "" {
+		req.Header.Set(matchHeader, validators.etag)
+	}
+
+	if validators.lastModified != "" {
+		req.Header.Set(dateHeader, validators.lastModified)
+	}
+
+	return nil
+}
+
+// responseFreshness returns how long caches can reuse the response, as defined in RFC 7234.
+func responseFreshness(header http.Header) (time.Duration, error) {
+	directives := parseCacheControl(header.Values("Cache-Control"))
+
+	if _, ok := directives["no-store"]; ok {
+		return 0, fmt.Errorf("expected the response to be cacheable, but Cache-Control has no-store")
+	}
+
+	if _, ok := directives["no-cache"]; ok {
+		return 0, nil
+	}
+
+	var lifetime time.Duration
+
+	if value, ok := directives["s-maxage"]; ok {
+		lifetime = parseDeltaSeconds(value)
+	} else if value, ok := directives["max-age"]; ok {
+		lifetime = parseDeltaSeconds(value)
+	} else if expires := header.Get("Expires"); expires != "" {
+		expiresAt, err := http.ParseTime(expires)
+
+		if err != nil {
+			return 0, nil
+		}
+
+		date, err := http.ParseTime(header.Get("Date"))
+		if err != nil {
+			date = time.Now()
+		}
+
+		lifetime = expiresAt.Sub(date)
+	}
+
+	age, err := responseAge(header)
+	if err != nil {
+		age = 0
+	}
+
+	return lifetime - time.Duration(age)*time.Second, nil
+}
+
+// responseAge returns the Age response header, or 0 when the response doesn't have it.
+func responseAge(header http.Header) (int, error) {
+	value := header.Get("Age")
+
+	if value == "" {
+		return 0, nil
+	}
+
+	age, err := strconv.Atoi(strings.TrimSpace(value))
+
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("expected the Age header to be a number of seconds, but it is %q", value)
+	}
+
+	return age, nil
+}
+
+// hasCacheControlDirective checks if the Cache-Control response header has the directive. A directive with a value, like max-age=60,
+// must have the same value.
+func hasCacheControlDirective(header http.Header, directive string) bool {
+	directives := parseCacheControl(header.Values("Cache-Control"))
+
+	name, expectedValue, hasValue := directive, "", false
+	if i := strings.Index(directive, "="); i >= 0 {
+		name, expectedValue, hasValue = directive[:i], strings.Trim(strings.TrimSpace(directive[i+1:]), `"`), true
+	}
+
+	value, ok := directives[strings.ToLower(strings.TrimSpace(name))]
+
+	return ok && (!hasValue || value == expectedValue)
+}
+
+// cacheHeadersDescription describes the caching headers of the response for the error messages.
+func cacheHeadersDescription(header http.Header) string {
+	var parts []string
+
+	for _, name := range []string{"Cache-Control", "Expires", "Date", "Age"} {
+		if value := header.Get(name); value != "" {
+			parts = append(parts, fmt.Sprintf("%s: %s", name, value))
+		}
+	}
+
+	if len(parts) == 0 {
+		return "The response has no caching headers"
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// parseCacheControl parses the directives of Cache-Control header lines into a map of lower case names to unquoted values.
+func parseCacheControl(lines []string) map[string]string {
+	directives := map[string]string{}
+
+	for _, directive := range splitHeaderValues(lines) {
+		name, value := directive, ""
+		if i := strings.Index(directive, "="); i >= 0 {
+			name, value = directive[:i], strings.Trim(strings.TrimSpace(directive[i+1:]), `"`)
+		}
+
+		directives[strings.ToLower(strings.TrimSpace(name))] = value
+	}
+
+	return directives
+}
+
+// parseDeltaSeconds parses the seconds of a directive like max-age. Invalid values mean the response is stale.
+func parseDeltaSeconds(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+
+	if err != nil || seconds < 0 {
+		return 0
+	}
+
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,139 @@
+package apicontext
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupCacheTestServer() *httptest.Server {
+	etag := `"v1"`
+	lastModified := "Wed, 21 Oct 2015 07:28:00 GMT"
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			if r.Header.Get("If-None-Match") == etag && r.Header.Get("If-Modified-Since") == lastModified {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case http.MethodPut:
+			if r.Header.Get("If-Match") != etag || r.Header.Get("If-Unmodified-Since") != lastModified {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			etag = `"v2"`
+		}
+
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", lastModified)
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		w.Header().Set("Vary", "Accept-Encoding, Accept")
+		w.Header().Set("Age", "100")
+		_, _ = w.Write([]byte(`{"id": 1}`))
+	}))
+}
+
+func TestApiContext_ISendAConditionalTo(t *testing.T) {
+	ts := setupCacheTestServer()
+	defer ts.Close()
+
+	ctx := New(ts.URL)
+
+	assert.EqualError(t, ctx.ISendAConditionalTo("GET", "/users/1"), "no response available. Send a request first")
+
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/users/1"))
+	assert.Nil(t, ctx.IStoreTheCacheValidatorsOfTheResponse())
+
+	assert.Nil(t, ctx.ISendAConditionalTo("GET", "/users/1"))
+	assert.Nil(t, ctx.TheResponseCodeShouldBe(304))
+
+	assert.Nil(t, ctx.ISendAConditionalToWithBody("PUT", "/users/1", &godog.DocString{Content: `{"name": "john"}`}))
+	assert.Nil(t, ctx.TheResponseCodeShouldBe(200))
+	assert.Equal(t, "application/json", ctx.lastRequest.Header.Get("Content-Type"))
+
+	assert.Nil(t, ctx.ISendAConditionalToWithBody("PUT", "/users/1", &godog.DocString{Content: `{"name": "jane"}`}))
+	assert.Nil(t, ctx.TheResponseCodeShouldBe(412))
+
+	ctx.reset(nil)
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/users/1"))
+	assert.Nil(t, ctx.ISendAConditionalTo("HEAD", "/users/1"))
+	assert.Equal(t, `"v2"`, ctx.lastRequest.Header.Get("If-None-Match"))
+	assert.Nil(t, ctx.TheResponseCodeShouldBe(304))
+}
+
+func TestApiContext_IStoreTheCacheValidatorsOfTheResponseWithoutValidators(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	ctx := New(ts.URL)
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+	assert.EqualError(t, ctx.IStoreTheCacheValidatorsOfTheResponse(), "expected the response to have an ETag or Last-Modified header")
+	assert.Error(t, ctx.ISendAConditionalTo("GET", "/"))
+}
+
+func TestApiContext_CacheAssertions(t *testing.T) {
+	ts := setupCacheTestServer()
+	defer ts.Close()
+
+	ctx := New(ts.URL)
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+
+	assert.Nil(t, ctx.TheResponseShouldBeCacheableForAtLeastSeconds(200))
+	assert.EqualError(t, ctx.TheResponseShouldBeCacheableForAtLeastSeconds(300),
+		"expected the response to be cacheable for at least 300 seconds, but it is fresh for 200 seconds. Cache-Control: public, max-age=300, Date: "+
+			ctx.lastResponse.ResponseObj.Header.Get("Date")+", Age: 100")
+	assert.Error(t, ctx.TheResponseShouldNotBeCacheable())
+
+	assert.Nil(t, ctx.TheResponseCacheControlShouldInclude("public"))
+	assert.Nil(t, ctx.TheResponseCacheControlShouldInclude("Max-Age=300"))
+	assert.EqualError(t, ctx.TheResponseCacheControlShouldInclude("max-age=60"), `expected Cache-Control to include max-age=60, but it is "public, max-age=300"`)
+	assert.Nil(t, ctx.TheResponseCacheControlShouldNotInclude("no-store"))
+	assert.Error(t, ctx.TheResponseCacheControlShouldNotInclude("max-age"))
+
+	assert.Nil(t, ctx.TheResponseShouldVaryBy("accept-encoding"))
+	assert.EqualError(t, ctx.TheResponseShouldVaryBy("Accept, Authorization"), `expected the response to vary by Authorization, but Vary is "Accept-Encoding, Accept"`)
+
+	assert.Nil(t, ctx.TheResponseAgeShouldBeAtMostSeconds(100))
+	assert.EqualError(t, ctx.TheResponseAgeShouldBeAtMostSeconds(60), "expected the response age to be at most 60 seconds, but it is 100")
+}
+
+func TestApiContext_ResponseFreshness(t *testing.T) {
+	now := time.Now().UTC()
+
+	tests := []struct {
+		header    http.Header
+		freshness time.Duration
+		err       bool
+	}{
+		{http.Header{"Cache-Control": {"max-age=60"}}, 60 * time.Second, false},
+		{http.Header{"Cache-Control": {"max-age=60, s-maxage=120"}}, 120 * time.Second, false},
+		{http.Header{"Cache-Control": {"max-age=\"60\""}, "Age": {"10"}}, 50 * time.Second, false},
+		{http.Header{"Cache-Control": {"no-cache, max-age=60"}}, 0, false},
+		{http.Header{"Cache-Control": {"max-age=abc"}}, 0, false},
+		{http.Header{"Cache-Control": {"private", "no-store"}}, 0, true},
+		{http.Header{"Date": {now.Format(http.TimeFormat)}, "Expires": {now.Add(time.Hour).Format(http.TimeFormat)}}, time.Hour, false},
+		{http.Header{"Expires": {"0"}}, 0, false},
+		{http.Header{}, 0, false},
+	}
+
+	for _, test := range tests {
+		ctx := New("https://example.com")
+		ctx.lastResponse = &ApiResponse{ResponseObj: &http.Response{Header: test.header}}
+
+		freshness, err := responseFreshness(test.header)
+
+		if test.err {
+			assert.Error(t, err, "%v", test.header)
+			assert.Nil(t, ctx.TheResponseShouldNotBeCacheable())
+			continue
+		}
+
+		assert.Nil(t, err, "%v", test.header)
+		assert.Equal(t, test.freshness, freshness, "%v", test.header)
+	}
+}
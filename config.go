@@ -0,0 +1,382 @@
+package apicontext
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cucumber/godog"
+	"gopkg.in/yaml.v3"
+)
+
+// ProfileEnvVar is the environment variable that selects the profile of the config file.
+const ProfileEnvVar = "APICONTEXT_PROFILE"
+
+// The prefix of the scenario tags that select a profile, like @env:staging.
+const profileTagPrefix = "@env:"
+
+// Config is the content of a config file, with the profiles of the environments where the features run.
+type Config struct {
+	DefaultProfile string             `yaml:"default_profile"`
+	Profiles       map[string]Profile `yaml:"profiles"`
+}
+
+// Profile configures the context for an environment.
+type Profile struct {
+	BaseURL         string                   `yaml:"base_url"`
+	Headers         map[string]string        `yaml:"headers"`
+	Timeout         string                   `yaml:"timeout"`
+	TLS             *TLSConfig               `yaml:"tls"`
+	Auth            *AuthConfig              `yaml:"auth"`
+	JSONSchemasPath string                   `yaml:"json_schemas_path"`
+	XMLSchemasPath  string                   `yaml:"xml_schemas_path"`
+	Scope           map[string]string        `yaml:"scope"`
+	Services        map[string]ServiceConfig `yaml:"services"`
+}
+
+// ServiceConfig configures a named service of a profile, see ApiContext.WithService.
+type ServiceConfig struct {
+	BaseURL string            `yaml:"base_url"`
+	Headers map[string]string `yaml:"headers"`
+	Auth    *AuthConfig       `yaml:"auth"`
+}
+
+// TLSConfig configures the TLS connections of a profile.
+type TLSConfig struct {
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+	CAFile             string `yaml:"ca_file"`
+	CertFile           string `yaml:"cert_file"`
+	KeyFile            string `yaml:"key_file"`
+}
+
+// AuthConfig configures the authentication header sent in every request of a profile.
+// Type is one of "bearer" (Token), "basic" (Username and Password) or "api_key" (Header and Token).
+type AuthConfig struct {
+	Type     string `yaml:"type"`
+	Token    string `yaml:"token"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	Header   string `yaml:"header"`
+}
+
+// NewFromConfig Creates a new instance of the API Context configured by a profile of the YAML or JSON config file.
+// The profile is selected by the APICONTEXT_PROFILE environment variable, or by the default_profile of the file,
+// and scenarios tagged with @env:<profile> run with that profile. Environment variables like ${TOKEN} are expanded in the file.
+// The With* options can be used to change the context further. They configure the context underneath the profiles,
+// so the settings of the active profile take precedence over them.
+func NewFromConfig(path string) (*ApiContext, error) {
+	contents, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot open config file: %v", err)
+	}
+
+	config := Config{}
+	if err := yaml.Unmarshal([]byte(os.ExpandEnv(string(contents))), &config); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+
+	if len(config.Profiles) == 0 {
+		return nil, fmt.Errorf("the config file %s has no profiles", path)
+	}
+
+	name := os.Getenv(ProfileEnvVar)
+	if name == "" {
+		name = config.DefaultProfile
+	}
+	if name == "" && len(config.Profiles) == 1 {
+		for profile := range config.Profiles {
+			name = profile
+		}
+	}
+
+	if name == "" {
+		return nil, fmt.Errorf("no profile selected. Set the %s environment variable or the default_profile of the config file", ProfileEnvVar)
+	}
+
+	ctx := New("")
+	ctx.profiles = config.Profiles
+	ctx.defaultProfile = name
+	ctx.profileBase = ctx.newProfileBase()
+
+	if err := ctx.applyProfile(name); err != nil {
+		return nil, err
+	}
+	ctx.headers = ctx.defaultHeadersCopy()
+
+	return ctx, nil
+}
+
+// selectProfile applies the profile of the scenario tag, or the default profile, when it's not the active profile.
+func (ctx *ApiContext) selectProfile(sc *godog.Scenario) {
+	ctx.profileErr = nil
+
+	if ctx.profiles == nil {
+		return
+	}
+
+	name := ctx.defaultProfile
+	if sc != nil {
+		for _, tag := range sc.GetTags() {
+			if strings.HasPrefix(tag.Name, profileTagPrefix) {
+				name = strings.TrimPrefix(tag.Name, profileTagPrefix)
+			}
+		}
+	}
+
+	if name == ctx.activeProfile {
+		return
+	}
+
+	ctx.profileErr = ctx.applyProfile(name)
+}
+
+// profileBase is the configuration the profiles are applied on: the settings of the context created by NewFromConfig
+// and the With* options. Switching profiles rebuilds the context from it, so no setting of a profile carries over to the next.
+type profileBase struct {
+	baseURL         string
+	jSONSchemasPath string
+	xmlSchemasPath  string
+	timeout         time.Duration
+	transport       http.RoundTripper
+	scope           map[string]string
+	services        map[string]*service
+}
+
+// newProfileBase takes a snapshot of the context settings that the profiles change.
+func (ctx *ApiContext) newProfileBase() *profileBase {
+	base := &profileBase{
+		baseURL:         ctx.baseURL,
+		jSONSchemasPath: ctx.jSONSchemasPath,
+		xmlSchemasPath:  ctx.xmlSchemasPath,
+		timeout:         ctx.client.Timeout,
+		transport:       ctx.client.Transport,
+		scope:           map[string]string{},
+		services:        copyServices(ctx.services),
+	}
+
+	if ctx.vcr != nil {
+		base.transport = ctx.vcr.next
+	}
+
+	for key, value := range ctx.scope {
+		base.scope[key] = value
+	}
+
+	return base
+}
+
+// updateProfileBase changes the base configuration of the profiles, for the With* options called after NewFromConfig,
+// and applies the active profile again on top of it.
+func (ctx *ApiContext) updateProfileBase(update func(base *profileBase)) {
+	if ctx.profileBase == nil {
+		return
+	}
+
+	update(ctx.profileBase)
+
+	if ctx.activeProfile == "" {
+		return
+	}
+
+	if err := ctx.applyProfile(ctx.activeProfile); err != nil {
+		ctx.profileErr = err
+	}
+}
+
+// applyProfile rebuilds the context from the base configuration and applies the settings of the profile on top of it.
+func (ctx *ApiContext) applyProfile(name string) error {
+	profile, ok := ctx.profiles[name]
+
+	if !ok {
+		names := make([]string, 0, len(ctx.profiles))
+		for profileName := range ctx.profiles {
+			names = append(names, profileName)
+		}
+		sort.Strings(names)
+
+		return fmt.Errorf("the profile %s does not exist in the config file. Available profiles: %s", name, strings.Join(names, ", "))
+	}
+
+	// a profile that fails to apply is applied again in the next scenario.
+	ctx.activeProfile = ""
+	ctx.restoreProfileBase()
+
+	if profile.Timeout != "" {
+		timeout, err := time.ParseDuration(profile.Timeout)
+
+		if err != nil {
+			return fmt.Errorf("invalid timeout of profile %s: %v", name, err)
+		}
+
+		ctx.client.Timeout = timeout
+	}
+
+	if profile.TLS != nil {
+		tlsConfig, err := profile.TLS.load()
+
+		if err != nil {
+			return fmt.Errorf("invalid tls config of profile %s: %v", name, err)
+		}
+
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		ctx.setTransport(transport)
+	}
+
+	ctx.profileHeaders = http.Header{}
+	for header, value := range profile.Headers {
+		ctx.profileHeaders.Set(header, value)
+	}
+
+	if profile.Auth != nil {
+		header, value, err := profile.Auth.header()
+
+		if err != nil {
+			return fmt.Errorf("invalid auth config of profile %s: %v", name, err)
+		}
+
+		ctx.profileHeaders.Set(header, value)
+		if profile.Auth.Token != "" || profile.Auth.Password != "" {
+			ctx.WithSecret(value)
+		}
+	}
+
+	for serviceName, svcConfig := range profile.Services {
+		svc := &service{baseURL: svcConfig.BaseURL, headers: map[string]string{}}
+
+		for header, value := range svcConfig.Headers {
+			svc.headers[header] = value
+		}
+
+		if svcConfig.Auth != nil {
+			header, value, err := svcConfig.Auth.header()
+
+			if err != nil {
+				return fmt.Errorf("invalid auth config of service %s of profile %s: %v", serviceName, name, err)
+			}
+
+			svc.headers[header] = value
+			if svcConfig.Auth.Token != "" || svcConfig.Auth.Password != "" {
+				ctx.WithSecret(value)
+			}
+		}
+
+		ctx.services[serviceName] = svc
+	}
+
+	if profile.BaseURL != "" {
+		ctx.baseURL = profile.BaseURL
+	}
+
+	if profile.JSONSchemasPath != "" {
+		ctx.jSONSchemasPath = profile.JSONSchemasPath
+	}
+
+	if profile.XMLSchemasPath != "" {
+		ctx.xmlSchemasPath = profile.XMLSchemasPath
+	}
+
+	for key, value := range profile.Scope {
+		ctx.scope[key] = value
+		ctx.profileScope = append(ctx.profileScope, key)
+	}
+
+	ctx.activeProfile = name
+	return nil
+}
+
+// restoreProfileBase resets the settings changed by the profiles to the base configuration.
+// Only the timeout and transport of the client are restored, so its other settings, like the cookie jar, are kept.
+// The scope variables set by the previous profile are restored, keeping the ones stored by the scenarios.
+func (ctx *ApiContext) restoreProfileBase() {
+	base := ctx.profileBase
+
+	ctx.client.Timeout = base.timeout
+	ctx.setTransport(base.transport)
+
+	ctx.baseURL = base.baseURL
+	ctx.jSONSchemasPath = base.jSONSchemasPath
+	ctx.xmlSchemasPath = base.xmlSchemasPath
+	ctx.services = copyServices(base.services)
+	ctx.profileHeaders = http.Header{}
+
+	for _, key := range ctx.profileScope {
+		if value, ok := base.scope[key]; ok {
+			ctx.scope[key] = value
+		} else {
+			delete(ctx.scope, key)
+		}
+	}
+	ctx.profileScope = nil
+}
+
+// setTransport replaces the transport of the client, keeping the record and replay transport in front of it.
+func (ctx *ApiContext) setTransport(transport http.RoundTripper) {
+	if ctx.vcr != nil {
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+
+		ctx.vcr.next = transport
+		ctx.client.Transport = ctx.vcr
+		return
+	}
+
+	ctx.client.Transport = transport
+}
+
+// load builds the TLS client config, loading the CA and client certificates.
+func (c *TLSConfig) load() (*tls.Config, error) {
+	// #nosec G402
+	config := &tls.Config{InsecureSkipVerify: c.InsecureSkipVerify}
+
+	if c.CAFile != "" {
+		ca, err := ioutil.ReadFile(c.CAFile)
+
+		if err != nil {
+			return nil, fmt.Errorf("cannot open CA file: %v", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("the CA file %s has no valid certificates", c.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+
+		if err != nil {
+			return nil, fmt.Errorf("cannot load client certificate: %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// header returns the name and value of the authentication header.
+func (c *AuthConfig) header() (string, string, error) {
+	switch strings.ToLower(c.Type) {
+	case "bearer":
+		return "Authorization", "Bearer " + c.Token, nil
+	case "basic":
+		return "Authorization", "Basic " + base64.StdEncoding.EncodeToString([]byte(c.Username+":"+c.Password)), nil
+	case "api_key":
+		if c.Header == "" {
+			return "", "", fmt.Errorf("the header of the api_key auth is required")
+		}
+		return c.Header, c.Token, nil
+	default:
+		return "", "", fmt.Errorf("unknown auth type %s, expected bearer, basic or api_key", c.Type)
+	}
+}
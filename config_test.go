@@ -0,0 +1,163 @@
+package apicontext
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/cucumber/messages-go/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewFromConfig(t *testing.T) {
+	os.Setenv("APICONTEXT_TEST_TOKEN", "staging-token")
+	defer os.Unsetenv("APICONTEXT_TEST_TOKEN")
+
+	ctx, err := NewFromConfig("testdata/config/profiles.yml")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "http://localhost:8080", ctx.baseURL)
+	assert.Equal(t, 5*time.Second, ctx.client.Timeout)
+	assert.Equal(t, http.Header{"X-Env": {"local"}}, ctx.headers)
+	assert.Equal(t, "acme", ctx.scope["tenant"])
+	assert.Equal(t, defaultSchemasPath, ctx.jSONSchemasPath)
+
+	ctx.reset(&godog.Scenario{Tags: []*messages.Pickle_PickleTag{{Name: "@smoke"}, {Name: "@env:staging"}}})
+	assert.Nil(t, ctx.profileErr)
+	assert.Equal(t, "https://staging.example.com", ctx.baseURL)
+	assert.Equal(t, "testdata/schemas", ctx.jSONSchemasPath)
+	assert.Equal(t, http.Header{"X-Env": {"staging"}, "Authorization": {"Bearer staging-token"}}, ctx.headers)
+	assert.Equal(t, "****", ctx.maskSecrets("Bearer staging-token"))
+
+	ctx.reset(&godog.Scenario{})
+	assert.Equal(t, "http://localhost:8080", ctx.baseURL)
+	assert.Equal(t, http.Header{"X-Env": {"local"}}, ctx.headers)
+
+	ctx.reset(&godog.Scenario{Tags: []*messages.Pickle_PickleTag{{Name: "@env:admin"}}})
+	assert.Equal(t, "Basic YWRtaW46c2VjcmV0", ctx.headers.Get("Authorization"))
+	assert.True(t, ctx.client.Transport.(*http.Transport).TLSClientConfig.InsecureSkipVerify)
+
+	ctx.reset(&godog.Scenario{})
+	assert.Nil(t, ctx.profileErr)
+	assert.Equal(t, "http://localhost:8080", ctx.baseURL)
+	assert.Equal(t, defaultSchemasPath, ctx.jSONSchemasPath)
+	assert.Equal(t, 5*time.Second, ctx.client.Timeout)
+	assert.Nil(t, ctx.client.Transport)
+	assert.Equal(t, http.Header{"X-Env": {"local"}}, ctx.headers)
+
+	ctx.reset(&godog.Scenario{Tags: []*messages.Pickle_PickleTag{{Name: "@env:admin"}}})
+	assert.Equal(t, time.Duration(0), ctx.client.Timeout)
+	assert.Equal(t, "", ctx.scope["tenant"])
+
+	ctx.reset(&godog.Scenario{Tags: []*messages.Pickle_PickleTag{{Name: "@env:production"}}})
+	assert.EqualError(t, ctx.profileErr, "the profile production does not exist in the config file. Available profiles: admin, broken, local, services, staging")
+	assert.EqualError(t, ctx.ISendRequestTo("GET", "/"), ctx.profileErr.Error())
+
+	ctx.reset(&godog.Scenario{Tags: []*messages.Pickle_PickleTag{{Name: "@env:broken"}}})
+	assert.Error(t, ctx.profileErr)
+}
+
+func TestNewFromConfigKeepsTheClientSettings(t *testing.T) {
+	ctx, err := NewFromConfig("testdata/config/profiles.yml")
+	assert.Nil(t, err)
+
+	jar, _ := cookiejar.New(nil)
+	checkRedirect := func(req *http.Request, via []*http.Request) error { return http.ErrUseLastResponse }
+	ctx.client.Jar = jar
+	ctx.client.CheckRedirect = checkRedirect
+
+	ctx.reset(&godog.Scenario{Tags: []*messages.Pickle_PickleTag{{Name: "@env:admin"}}})
+	assert.Nil(t, ctx.profileErr)
+	ctx.reset(&godog.Scenario{})
+
+	assert.Equal(t, jar, ctx.client.Jar)
+	assert.NotNil(t, ctx.client.CheckRedirect)
+	assert.Equal(t, 5*time.Second, ctx.client.Timeout)
+	assert.Nil(t, ctx.client.Transport)
+}
+
+func TestNewFromConfigWithProfileEnvVar(t *testing.T) {
+	os.Setenv(ProfileEnvVar, "admin")
+	defer os.Unsetenv(ProfileEnvVar)
+
+	ctx, err := NewFromConfig("testdata/config/profiles.yml")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "https://admin.example.com", ctx.WithDebug(true).baseURL)
+
+	os.Setenv(ProfileEnvVar, "broken")
+	_, err = NewFromConfig("testdata/config/profiles.yml")
+	assert.EqualError(t, err, `invalid timeout of profile broken: time: invalid duration "soon"`)
+}
+
+func TestNewFromConfigWithJSONFile(t *testing.T) {
+	ctx, err := NewFromConfig("testdata/config/profiles.json")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "http://api:8080", ctx.baseURL)
+	assert.Equal(t, "key", ctx.headers.Get("X-Api-Key"))
+
+	_, err = NewFromConfig("testdata/config/missing.yml")
+	assert.Error(t, err)
+
+	_, err = NewFromConfig("testdata/test_json_path.json")
+	assert.EqualError(t, err, "the config file testdata/test_json_path.json has no profiles")
+}
+
+func TestNewFromConfigWithServices(t *testing.T) {
+	os.Setenv(ProfileEnvVar, "services")
+	defer os.Unsetenv(ProfileEnvVar)
+
+	ctx, err := NewFromConfig("testdata/config/profiles.yml")
+
+	assert.Nil(t, err)
+	authURL, err := ctx.requestURL("auth:/token")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://auth.example.com/token", authURL)
+	assert.Equal(t, "auth-key", ctx.requestHeader("auth:/token").Get("X-Api-Key"))
+	assert.Equal(t, "tests", ctx.requestHeader("auth:/token").Get("X-Client"))
+	gatewayURL, err := ctx.requestURL("/orders")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://gateway.example.com/orders", gatewayURL)
+	assert.Empty(t, ctx.requestHeader("/orders").Get("X-Api-Key"))
+
+	assert.Equal(t, "Bearer gateway-token", ctx.requestHeader("/orders").Get("Authorization"))
+	assert.Equal(t, "Bearer service-token", ctx.requestHeader("auth:/token").Get("Authorization"))
+	assert.Nil(t, ctx.ISetHeaderWithValue("Authorization", "Bearer user-token"))
+	assert.Equal(t, "Bearer user-token", ctx.requestHeader("auth:/token").Get("Authorization"))
+}
+
+func TestNewFromConfigWithOptionsUnderneathProfiles(t *testing.T) {
+	os.Setenv("APICONTEXT_TEST_TOKEN", "staging-token")
+	defer os.Unsetenv("APICONTEXT_TEST_TOKEN")
+
+	ctx, err := NewFromConfig("testdata/config/profiles.yml")
+	assert.Nil(t, err)
+
+	ctx.WithJSONSchemasPath("testdata/other").
+		WithBaseURL("http://override:8080").
+		WithService("billing", "https://billing.example.com")
+
+	// the base URL of the active profile takes precedence over the option.
+	assert.Equal(t, "http://localhost:8080", ctx.baseURL)
+	assert.Equal(t, "testdata/other", ctx.jSONSchemasPath)
+
+	ctx.reset(&godog.Scenario{Tags: []*messages.Pickle_PickleTag{{Name: "@env:staging"}}})
+	assert.Equal(t, "testdata/schemas", ctx.jSONSchemasPath)
+
+	ctx.reset(&godog.Scenario{Tags: []*messages.Pickle_PickleTag{{Name: "@env:services"}}})
+	assert.Equal(t, "testdata/other", ctx.jSONSchemasPath)
+	assert.Equal(t, []string{"auth", "billing"}, ctx.serviceNames())
+
+	ctx.reset(&godog.Scenario{Tags: []*messages.Pickle_PickleTag{{Name: "@env:broken"}}})
+	assert.Error(t, ctx.profileErr)
+	assert.Equal(t, "http://override:8080", ctx.baseURL)
+	assert.Equal(t, []string{"billing"}, ctx.serviceNames())
+
+	ctx.reset(&godog.Scenario{})
+	assert.Nil(t, ctx.profileErr)
+	assert.Equal(t, "http://localhost:8080", ctx.baseURL)
+}
@@ -0,0 +1,151 @@
+package apicontext
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/cucumber/godog"
+)
+
+// docStringMediaTypes maps the media types of the DocStrings, like ```json, to the Content-Type they send.
+var docStringMediaTypes = map[string]string{
+	"json":    "application/json",
+	"xml":     "application/xml",
+	"yaml":    "application/yaml",
+	"yml":     "application/yaml",
+	"csv":     "text/csv",
+	"ndjson":  "application/x-ndjson",
+	"form":    "application/x-www-form-urlencoded",
+	"graphql": "application/graphql",
+	"html":    "text/html",
+	"text":    "text/plain",
+	"txt":     "text/plain",
+}
+
+// WithAutoContentType Configures if the body steps detect the Content-Type of bodies without a DocString media type,
+// like ```json, when it's not set in the scenario. It's enabled by default.
+func (ctx *ApiContext) WithAutoContentType(enabled bool) *ApiContext {
+	ctx.autoContentType = enabled
+	return ctx
+}
+
+// WithDefaultAccept Configures the Accept header sent in the requests that don't set one, like "application/json".
+func (ctx *ApiContext) WithDefaultAccept(accept string) *ApiContext {
+	ctx.defaultAccept = accept
+	return ctx
+}
+
+// TheResponseContentTypeShouldBe Checks the media type of the response Content-Type, ignoring its case.
+// The parameters, like charset, are only checked when they are part of the expected value.
+func (ctx *ApiContext) TheResponseContentTypeShouldBe(expected string) error {
+	expectedType, expectedParams, err := mime.ParseMediaType(ctx.ReplaceScopeVariables(expected))
+
+	if err != nil {
+		return fmt.Errorf("invalid content type %s: %v", expected, err)
+	}
+
+	header, err := ctx.responseHeader()
+
+	if err != nil {
+		return err
+	}
+
+	actual := header.Get("Content-Type")
+	actualType, actualParams, err := mime.ParseMediaType(actual)
+
+	if err != nil {
+		return fmt.Errorf("expected the response content type to be %s, but it is not a valid content type: %q", expected, actual)
+	}
+
+	if actualType != expectedType {
+		return fmt.Errorf("expected the response content type to be %s. actual: %s", expected, actual)
+	}
+
+	for name, value := range expectedParams {
+		if !strings.EqualFold(actualParams[name], value) {
+			return fmt.Errorf("expected the response content type to have %s=%s. actual: %s", name, value, actual)
+		}
+	}
+
+	return nil
+}
+
+// bodyContentType returns the Content-Type for a request body: the one of the DocString media type, when it's set,
+// or the one detected from the body.
+func (ctx *ApiContext) bodyContentType(doc *godog.DocString, body []byte) string {
+	if mediaType := strings.TrimSpace(doc.MediaType); mediaType != "" {
+		if strings.Contains(mediaType, "/") {
+			return mediaType
+		}
+
+		if contentType, ok := docStringMediaTypes[strings.ToLower(mediaType)]; ok {
+			return contentType
+		}
+	}
+
+	if !ctx.autoContentType {
+		return ""
+	}
+
+	return sniffContentType(body)
+}
+
+// sniffContentType detects the Content-Type of a request body. JSON and XML are detected by parsing the body,
+// the other types with http.DetectContentType.
+func sniffContentType(body []byte) string {
+	trimmed := bytes.TrimSpace(body)
+
+	if len(trimmed) == 0 {
+		return ""
+	}
+
+	if (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed) {
+		return "application/json"
+	}
+
+	if trimmed[0] == '<' && isXML(trimmed) {
+		return "application/xml"
+	}
+
+	return http.DetectContentType(body)
+}
+
+// isXML checks if the body is a well formed XML document. HTML documents, which usually aren't, are detected by http.DetectContentType.
+func isXML(body []byte) bool {
+	if strings.HasPrefix(http.DetectContentType(body), "text/html") {
+		return false
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		_, err := decoder.Token()
+
+		if err == io.EOF {
+			return true
+		}
+
+		if err != nil {
+			return false
+		}
+	}
+}
+
+// setBodyContentType sets the Content-Type of the request body, unless it was set or removed in the scenario.
+func (ctx *ApiContext) setBodyContentType(req *http.Request, contentType string) {
+	if contentType != "" && req.Header.Get("Content-Type") == "" && !ctx.removedHeaders["Content-Type"] {
+		req.Header.Set("Content-Type", contentType)
+	}
+}
+
+// applyDefaultAccept sets the default Accept header, unless it was set or removed in the scenario.
+func (ctx *ApiContext) applyDefaultAccept(req *http.Request) {
+	if ctx.defaultAccept != "" && req.Header.Get("Accept") == "" && !ctx.removedHeaders["Accept"] {
+		req.Header.Set("Accept", ctx.defaultAccept)
+	}
+}
@@ -0,0 +1,105 @@
+package apicontext
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSniffContentType(t *testing.T) {
+	tests := []struct {
+		body     string
+		expected string
+	}{
+		{`{"name": "john"}`, "application/json"},
+		{"  [1, 2]\n", "application/json"},
+		{`<?xml version="1.0"?><user><name>john</name></user>`, "application/xml"},
+		{`<user><name>john</name></user>`, "application/xml"},
+		{`<html><body>hello</body></html>`, "text/html; charset=utf-8"},
+		{`{not json`, "text/plain; charset=utf-8"},
+		{"name=john&age=30", "text/plain; charset=utf-8"},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, sniffContentType([]byte(test.body)), test.body)
+	}
+}
+
+func TestApiContext_ISendRequestToWithBodyContentType(t *testing.T) {
+	var received receivedRequest
+	ts := setupHeadersTestServer(&received)
+	defer ts.Close()
+
+	ctx := New(ts.URL)
+
+	assert.Nil(t, ctx.ISendRequestToWithBody("POST", "/", &godog.DocString{Content: `{"id": 1}`}))
+	assert.Equal(t, "application/json", received.header.Get("Content-Type"))
+
+	assert.Nil(t, ctx.ISendRequestToWithBody("POST", "/", &godog.DocString{Content: "id: 1", MediaType: "yaml"}))
+	assert.Equal(t, "application/yaml", received.header.Get("Content-Type"))
+
+	assert.Nil(t, ctx.ISendRequestToWithBody("POST", "/", &godog.DocString{Content: "{}", MediaType: "application/vnd.api+json"}))
+	assert.Equal(t, "application/vnd.api+json", received.header.Get("Content-Type"))
+
+	assert.Nil(t, ctx.ISetHeaderWithValue("Content-Type", "text/plain"))
+	assert.Nil(t, ctx.ISendRequestToWithBody("POST", "/", &godog.DocString{Content: "{}", MediaType: "json"}))
+	assert.Equal(t, "text/plain", received.header.Get("Content-Type"))
+
+	assert.Nil(t, ctx.IRemoveHeader("Content-Type"))
+	assert.Nil(t, ctx.ISendRequestToWithBody("POST", "/", &godog.DocString{Content: "{}", MediaType: "json"}))
+	assert.NotContains(t, received.header, "Content-Type")
+
+	ctx.reset(nil)
+	ctx.WithAutoContentType(false)
+	assert.Nil(t, ctx.ISendRequestToWithBody("POST", "/", &godog.DocString{Content: "{}"}))
+	assert.NotContains(t, received.header, "Content-Type")
+
+	assert.Nil(t, ctx.ISendRequestToWithBody("POST", "/", &godog.DocString{Content: "{}", MediaType: "json"}))
+	assert.Equal(t, "application/json", received.header.Get("Content-Type"))
+}
+
+func TestApiContext_WithDefaultAccept(t *testing.T) {
+	var received receivedRequest
+	ts := setupHeadersTestServer(&received)
+	defer ts.Close()
+
+	ctx := New(ts.URL).WithDefaultAccept("application/json")
+
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+	assert.Equal(t, "application/json", received.header.Get("Accept"))
+
+	assert.Nil(t, ctx.ISetHeaderWithValue("Accept", "text/csv"))
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+	assert.Equal(t, "text/csv", received.header.Get("Accept"))
+
+	assert.Nil(t, ctx.IRemoveHeader("Accept"))
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+	assert.NotContains(t, received.header, "Accept")
+}
+
+func TestApiContext_TheResponseContentTypeShouldBe(t *testing.T) {
+	contentType := "application/json; charset=UTF-8"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+	}))
+	defer ts.Close()
+
+	ctx := New(ts.URL)
+
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+	assert.Nil(t, ctx.TheResponseContentTypeShouldBe("application/json"))
+	assert.Nil(t, ctx.TheResponseContentTypeShouldBe("Application/JSON; charset=utf-8"))
+	assert.EqualError(t, ctx.TheResponseContentTypeShouldBe("application/json; charset=iso-8859-1"),
+		"expected the response content type to have charset=iso-8859-1. actual: application/json; charset=UTF-8")
+	assert.EqualError(t, ctx.TheResponseContentTypeShouldBe("application/xml"),
+		"expected the response content type to be application/xml. actual: application/json; charset=UTF-8")
+	assert.Error(t, ctx.TheResponseContentTypeShouldBe("application/json; charset"))
+
+	contentType = "json"
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+	assert.Error(t, ctx.TheResponseContentTypeShouldBe("application/json"))
+}
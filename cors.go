@@ -0,0 +1,193 @@
+package apicontext
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ISendACORSPreflightForToFromOrigin Sends the OPTIONS request a browser sends before a cross-origin request with the method.
+func (ctx *ApiContext) ISendACORSPreflightForToFromOrigin(method string, uri string, origin string) error {
+	return ctx.ISendACORSPreflightForToFromOriginWithHeaders(method, uri, origin, "")
+}
+
+// ISendACORSPreflightForToFromOriginWithHeaders Sends the OPTIONS request a browser sends before a cross-origin request
+// with the method and the comma separated request headers, like "Content-Type, Authorization".
+func (ctx *ApiContext) ISendACORSPreflightForToFromOriginWithHeaders(method string, uri string, origin string, headers string) error {
+	req, err := ctx.newRequest(http.MethodOptions, uri, nil)
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Origin", ctx.ReplaceScopeVariables(origin))
+	req.Header.Set("Access-Control-Request-Method", strings.ToUpper(method))
+
+	if names := splitHeaderValues([]string{headers}); len(names) > 0 {
+		req.Header.Set("Access-Control-Request-Headers", strings.ToLower(strings.Join(names, ",")))
+	}
+
+	return ctx.sendRequest(req)
+}
+
+// TheResponseShouldAllowTheOrigin Checks that the Access-Control-Allow-Origin response header allows the origin.
+func (ctx *ApiContext) TheResponseShouldAllowTheOrigin(origin string) error {
+	header, err := ctx.responseHeader()
+
+	if err != nil {
+		return err
+	}
+
+	origin = ctx.ReplaceScopeVariables(origin)
+	allowed := header.Get("Access-Control-Allow-Origin")
+
+	if allowed == origin || allowed == "*" && corsAllowsWildcard(header) {
+		return nil
+	}
+
+	return fmt.Errorf("expected the response to allow the origin %s, but Access-Control-Allow-Origin is %q", origin, allowed)
+}
+
+// TheResponseShouldNotAllowTheOrigin Checks that the Access-Control-Allow-Origin response header doesn't allow the origin.
+func (ctx *ApiContext) TheResponseShouldNotAllowTheOrigin(origin string) error {
+	header, err := ctx.responseHeader()
+
+	if err != nil {
+		return err
+	}
+
+	if ctx.TheResponseShouldAllowTheOrigin(origin) == nil {
+		return fmt.Errorf("expected the response not to allow the origin %s, but Access-Control-Allow-Origin is %q",
+			ctx.ReplaceScopeVariables(origin), header.Get("Access-Control-Allow-Origin"))
+	}
+
+	return nil
+}
+
+// TheResponseShouldAllowTheMethod Checks that the Access-Control-Allow-Methods response header lists the method.
+func (ctx *ApiContext) TheResponseShouldAllowTheMethod(method string) error {
+	header, err := ctx.responseHeader()
+
+	if err != nil {
+		return err
+	}
+
+	allowed := corsHeaderValues(header, "Access-Control-Allow-Methods")
+
+	for _, value := range allowed {
+		if value == strings.ToUpper(method) || value == "*" && corsAllowsWildcard(header) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("expected the response to allow the method %s, but Access-Control-Allow-Methods is %q", method, strings.Join(allowed, ", "))
+}
+
+// TheResponseShouldAllowTheHeaders Checks that the Access-Control-Allow-Headers response header lists the comma separated headers.
+func (ctx *ApiContext) TheResponseShouldAllowTheHeaders(headers string) error {
+	header, err := ctx.responseHeader()
+
+	if err != nil {
+		return err
+	}
+
+	allowed := corsHeaderValues(header, "Access-Control-Allow-Headers")
+
+	if missing := missingHeaderNames(splitHeaderValues([]string{headers}), allowed, corsAllowsWildcard(header)); len(missing) > 0 {
+		return fmt.Errorf("expected the response to allow the headers %s, but Access-Control-Allow-Headers is %q", strings.Join(missing, ", "), strings.Join(allowed, ", "))
+	}
+
+	return nil
+}
+
+// TheResponseShouldExposeTheHeaders Checks that the Access-Control-Expose-Headers response header lists the comma separated headers,
+// so the browser scripts can read them.
+func (ctx *ApiContext) TheResponseShouldExposeTheHeaders(headers string) error {
+	header, err := ctx.responseHeader()
+
+	if err != nil {
+		return err
+	}
+
+	exposed := corsHeaderValues(header, "Access-Control-Expose-Headers")
+
+	if missing := missingHeaderNames(splitHeaderValues([]string{headers}), exposed, corsAllowsWildcard(header)); len(missing) > 0 {
+		return fmt.Errorf("expected the response to expose the headers %s, but Access-Control-Expose-Headers is %q", strings.Join(missing, ", "), strings.Join(exposed, ", "))
+	}
+
+	return nil
+}
+
+// TheResponseShouldAllowCredentials Checks that the response allows requests with credentials, like cookies.
+// The browsers don't accept the * wildcard in the Access-Control-Allow-Origin of these responses.
+func (ctx *ApiContext) TheResponseShouldAllowCredentials() error {
+	header, err := ctx.responseHeader()
+
+	if err != nil {
+		return err
+	}
+
+	if header.Get("Access-Control-Allow-Credentials") != "true" {
+		return fmt.Errorf("expected the response to allow credentials, but Access-Control-Allow-Credentials is %q", header.Get("Access-Control-Allow-Credentials"))
+	}
+
+	if header.Get("Access-Control-Allow-Origin") == "*" {
+		return fmt.Errorf("expected the response to allow credentials, but Access-Control-Allow-Origin is the * wildcard, which the browsers reject with credentials")
+	}
+
+	return nil
+}
+
+// TheCORSMaxAgeShouldBeAtLeastSeconds Checks that the browsers can cache the preflight response, with Access-Control-Max-Age, for the seconds.
+func (ctx *ApiContext) TheCORSMaxAgeShouldBeAtLeastSeconds(seconds int) error {
+	header, err := ctx.responseHeader()
+
+	if err != nil {
+		return err
+	}
+
+	value := header.Get("Access-Control-Max-Age")
+	maxAge, err := strconv.Atoi(strings.TrimSpace(value))
+
+	if err != nil {
+		return fmt.Errorf("expected Access-Control-Max-Age to be a number of seconds, but it is %q", value)
+	}
+
+	if maxAge < seconds {
+		return fmt.Errorf("expected Access-Control-Max-Age to be at least %d seconds, but it is %d", seconds, maxAge)
+	}
+
+	return nil
+}
+
+// corsHeaderValues returns the comma separated values of a CORS response header.
+func corsHeaderValues(header http.Header, name string) []string {
+	return splitHeaderValues(header.Values(name))
+}
+
+// corsAllowsWildcard checks if the * wildcard of the CORS response headers applies. It doesn't for requests with credentials.
+func corsAllowsWildcard(header http.Header) bool {
+	return header.Get("Access-Control-Allow-Credentials") != "true"
+}
+
+// missingHeaderNames returns the header names that are not listed, ignoring their case.
+func missingHeaderNames(names []string, listed []string, wildcard bool) []string {
+	var missing []string
+
+	for _, name := range names {
+		found := false
+		for _, value := range listed {
+			if strings.EqualFold(value, name) || value == "*" && wildcard {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			missing = append(missing, name)
+		}
+	}
+
+	return missing
+}
@@ -0,0 +1,90 @@
+package apicontext
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupCORSTestServer(t *testing.T, credentials bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodOptions, r.Method)
+
+		origin := r.Header.Get("Origin")
+		if origin != "https://app.example.com" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		if credentials {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		}
+
+		w.Header().Set("Access-Control-Allow-Methods", r.Header.Get("Access-Control-Request-Method")+", GET")
+		w.Header().Set("Access-Control-Allow-Headers", r.Header.Get("Access-Control-Request-Headers"))
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-Id, Link")
+		w.Header().Set("Access-Control-Max-Age", "600")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+}
+
+func TestApiContext_ISendACORSPreflightForToFromOrigin(t *testing.T) {
+	ts := setupCORSTestServer(t, true)
+	defer ts.Close()
+
+	ctx := New(ts.URL)
+
+	assert.Nil(t, ctx.ISendACORSPreflightForToFromOriginWithHeaders("post", "/orders", "https://app.example.com", "Content-Type, X-Api-Key"))
+	assert.Equal(t, "content-type,x-api-key", ctx.lastRequest.Header.Get("Access-Control-Request-Headers"))
+	assert.Nil(t, ctx.TheResponseCodeShouldBe(204))
+
+	assert.Nil(t, ctx.TheResponseShouldAllowTheOrigin("https://app.example.com"))
+	assert.EqualError(t, ctx.TheResponseShouldAllowTheOrigin("https://evil.example.com"),
+		`expected the response to allow the origin https://evil.example.com, but Access-Control-Allow-Origin is "https://app.example.com"`)
+	assert.Nil(t, ctx.TheResponseShouldNotAllowTheOrigin("https://evil.example.com"))
+	assert.Error(t, ctx.TheResponseShouldNotAllowTheOrigin("https://app.example.com"))
+
+	assert.Nil(t, ctx.TheResponseShouldAllowTheMethod("POST"))
+	assert.Nil(t, ctx.TheResponseShouldAllowTheMethod("get"))
+	assert.EqualError(t, ctx.TheResponseShouldAllowTheMethod("DELETE"),
+		`expected the response to allow the method DELETE, but Access-Control-Allow-Methods is "POST, GET"`)
+
+	assert.Nil(t, ctx.TheResponseShouldAllowTheHeaders("X-Api-Key, content-type"))
+	assert.EqualError(t, ctx.TheResponseShouldAllowTheHeaders("Content-Type, Authorization"),
+		`expected the response to allow the headers Authorization, but Access-Control-Allow-Headers is "content-type, x-api-key"`)
+	assert.Nil(t, ctx.TheResponseShouldExposeTheHeaders("link"))
+	assert.Error(t, ctx.TheResponseShouldExposeTheHeaders("ETag"))
+
+	assert.Nil(t, ctx.TheResponseShouldAllowCredentials())
+	assert.Nil(t, ctx.TheCORSMaxAgeShouldBeAtLeastSeconds(600))
+	assert.EqualError(t, ctx.TheCORSMaxAgeShouldBeAtLeastSeconds(3600), "expected Access-Control-Max-Age to be at least 3600 seconds, but it is 600")
+
+	assert.Nil(t, ctx.ISendACORSPreflightForToFromOrigin("PUT", "/orders", "https://evil.example.com"))
+	assert.Nil(t, ctx.TheResponseShouldNotAllowTheOrigin("https://evil.example.com"))
+	assert.Error(t, ctx.TheResponseShouldAllowTheMethod("PUT"))
+	assert.Error(t, ctx.TheResponseShouldAllowCredentials())
+	assert.EqualError(t, ctx.TheCORSMaxAgeShouldBeAtLeastSeconds(1), `expected Access-Control-Max-Age to be a number of seconds, but it is ""`)
+}
+
+func TestApiContext_CORSWildcard(t *testing.T) {
+	ts := setupCORSTestServer(t, false)
+	defer ts.Close()
+
+	ctx := New(ts.URL)
+
+	assert.Nil(t, ctx.ISendACORSPreflightForToFromOrigin("DELETE", "/orders/1", "https://app.example.com"))
+	assert.Empty(t, ctx.lastRequest.Header.Get("Access-Control-Request-Headers"))
+	assert.Nil(t, ctx.TheResponseShouldAllowTheOrigin("https://other.example.com"))
+	assert.Nil(t, ctx.TheResponseShouldAllowTheMethod("DELETE"))
+	assert.EqualError(t, ctx.TheResponseShouldAllowCredentials(), `expected the response to allow credentials, but Access-Control-Allow-Credentials is ""`)
+
+	ctx.lastResponse.ResponseObj.Header.Set("Access-Control-Allow-Credentials", "true")
+	assert.Error(t, ctx.TheResponseShouldAllowTheOrigin("https://other.example.com"))
+	assert.EqualError(t, ctx.TheResponseShouldAllowCredentials(),
+		"expected the response to allow credentials, but Access-Control-Allow-Origin is the * wildcard, which the browsers reject with credentials")
+}
@@ -0,0 +1,274 @@
+package apicontext
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The version of the HAR format written by WriteHARFile.
+const harVersion = "1.2"
+
+// harLog is the root of a HAR file. See http://www.softwareishard.com/blog/har-12-spec/
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// WithCurlOnFailure Configures if the requests sent by a failed scenario are printed as curl commands, to reproduce them. Enabled by default.
+func (ctx *ApiContext) WithCurlOnFailure(enabled bool) *ApiContext {
+	ctx.curlOnFailure = enabled
+	return ctx
+}
+
+// WithHARFile Records the requests sent by all the scenarios, to be written as a HAR 1.2 file by WriteHARFile.
+// HAR files can be imported in the browser developer tools or in Postman.
+func (ctx *ApiContext) WithHARFile(path string) *ApiContext {
+	ctx.harPath = path
+	return ctx
+}
+
+// WriteHARFile writes the requests recorded since the start of the Test suite to the file configured with WithHARFile.
+// It should be called when the Test suite finishes.
+func (ctx *ApiContext) WriteHARFile() error {
+	if ctx.harPath == "" {
+		return fmt.Errorf("no HAR file configured. Configure it with WithHARFile")
+	}
+
+	entries := ctx.harEntries
+	if entries == nil {
+		entries = []harEntry{}
+	}
+
+	contents, err := json.MarshalIndent(map[string]harLog{
+		"log": {
+			Version: harVersion,
+			Creator: harCreator{Name: "godog-api-context"},
+			Entries: entries,
+		},
+	}, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(ctx.harPath), 0750); err != nil {
+		return fmt.Errorf("cannot create HAR file directory: %v", err)
+	}
+
+	if err := ioutil.WriteFile(ctx.harPath, []byte(ctx.maskSecrets(string(contents))), 0600); err != nil {
+		return fmt.Errorf("cannot write HAR file: %v", err)
+	}
+
+	return nil
+}
+
+// LastRequestAsCurl returns the last request sent in the scenario as a curl command, or an empty string if no request was sent.
+func (ctx *ApiContext) LastRequestAsCurl() string {
+	if len(ctx.exchanges) == 0 {
+		return ""
+	}
+
+	e := ctx.exchanges[len(ctx.exchanges)-1]
+
+	return ctx.maskSecrets(curlCommand(e.request, e.requestBody, ctx.redactHeaders))
+}
+
+// printCurlCommands logs the requests sent in the scenario as curl commands.
+func (ctx *ApiContext) printCurlCommands() {
+	if !ctx.curlOnFailure || len(ctx.exchanges) == 0 {
+		return
+	}
+
+	commands := make([]string, 0, len(ctx.exchanges))
+	for _, e := range ctx.exchanges {
+		commands = append(commands, curlCommand(e.request, e.requestBody, ctx.redactHeaders))
+	}
+
+	ctx.log(LogEntry{
+		Level:   LogLevelError,
+		Message: "requests sent by the failed scenario",
+		Fields: []LogField{
+			{Key: "scenario", Value: ctx.scenarioName},
+			{Key: "body", Value: strings.Join(commands, "\n\n")},
+		},
+	})
+}
+
+// curlCommand renders the request as a curl command, with its headers, passed through redact, and body.
+// The Host header is added when it overrides the host of the URL.
+func curlCommand(req *http.Request, body []byte, redact func(header http.Header) http.Header) string {
+	parts := []string{"curl -X " + shellQuote(req.Method) + " " + shellQuote(req.URL.String())}
+
+	header := redact(req.Header)
+	if req.Host != "" && req.Host != req.URL.Host {
+		header.Set("Host", req.Host)
+	}
+
+	for _, header := range sortedHeaders(header) {
+		parts = append(parts, "-H "+shellQuote(header.Name+": "+header.Value))
+	}
+
+	if len(body) > 0 {
+		parts = append(parts, "--data-raw "+shellQuote(string(body)))
+	}
+
+	return strings.Join(parts, " \\\n  ")
+}
+
+// shellQuote quotes the value to be used as a single argument in a POSIX shell.
+func shellQuote(value string) string {
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
+
+// sortedHeaders returns the headers as name value pairs, sorted by name.
+func sortedHeaders(header http.Header) []harNameValue {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := []harNameValue{}
+	for _, name := range names {
+		for _, value := range header[name] {
+			pairs = append(pairs, harNameValue{Name: name, Value: value})
+		}
+	}
+
+	return pairs
+}
+
+// newHAREntry converts the exchange to a HAR entry, with the headers passed through redact. Failed requests have a response with status 0.
+func newHAREntry(e *exchange, scenarioName string, redact func(header http.Header) http.Header) harEntry {
+	milliseconds := float64(e.duration) / float64(time.Millisecond)
+
+	query := []harNameValue{}
+	for name, values := range e.request.URL.Query() {
+		for _, value := range values {
+			query = append(query, harNameValue{Name: name, Value: value})
+		}
+	}
+	sort.SliceStable(query, func(i, j int) bool { return query[i].Name < query[j].Name })
+
+	entry := harEntry{
+		StartedDateTime: e.startedAt.Format(time.RFC3339Nano),
+		Time:            milliseconds,
+		Request: harRequest{
+			Method:      e.request.Method,
+			URL:         e.request.URL.String(),
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harNameValue{},
+			Headers:     sortedHeaders(redact(e.request.Header)),
+			QueryString: query,
+			HeadersSize: -1,
+			BodySize:    len(e.requestBody),
+		},
+		Response: harResponse{
+			Cookies:     []harNameValue{},
+			Headers:     []harNameValue{},
+			HTTPVersion: "HTTP/1.1",
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Timings: harTimings{Send: 0, Wait: milliseconds, Receive: 0},
+		Comment: scenarioName,
+	}
+
+	if len(e.requestBody) > 0 {
+		entry.Request.PostData = &harPostData{
+			MimeType: e.request.Header.Get("Content-Type"),
+			Text:     string(e.requestBody),
+		}
+	}
+
+	if e.response != nil {
+		entry.Response.Status = e.response.StatusCode
+		entry.Response.StatusText = http.StatusText(e.response.StatusCode)
+		entry.Response.BodySize = len(e.response.Body)
+		entry.Response.Content = harContent{
+			Size: len(e.response.Body),
+			Text: e.response.Body,
+		}
+
+		if e.response.ResponseObj != nil {
+			entry.Response.Headers = sortedHeaders(redact(e.response.ResponseObj.Header))
+			entry.Response.Content.MimeType = e.response.ResponseObj.Header.Get("Content-Type")
+			entry.Response.RedirectURL = e.response.ResponseObj.Header.Get("Location")
+
+			if e.response.ResponseObj.Proto != "" {
+				entry.Response.HTTPVersion = e.response.ResponseObj.Proto
+			}
+		}
+	}
+
+	return entry
+}
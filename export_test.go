@@ -0,0 +1,118 @@
+package apicontext
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiContext_LastRequestAsCurl(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+
+	ctx := New(ts.URL)
+	assert.Equal(t, "", ctx.LastRequestAsCurl())
+
+	assert.Nil(t, ctx.ISetHeaderWithValue("Content-Type", "application/json"))
+	assert.Nil(t, ctx.ISetHeaderWithValue("X-Name", "O'Brien"))
+	assert.Nil(t, ctx.ISendRequestToWithBody("POST", "/users?active=true", &godog.DocString{Content: `{"name": "O'Brien"}`}))
+
+	assert.Equal(t, "curl -X 'POST' '"+ts.URL+"/users?active=true' \\\n"+
+		"  -H 'Content-Type: application/json' \\\n"+
+		"  -H 'X-Name: O'\\''Brien' \\\n"+
+		"  --data-raw '{\"name\": \"O'\\''Brien\"}'", ctx.LastRequestAsCurl())
+
+	var entries []LogEntry
+	ctx.WithLogger(LoggerFunc(func(entry LogEntry) {
+		entries = append(entries, entry)
+	}))
+
+	ctx.scenarioName = "create user"
+	ctx.printCurlCommands()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, LogLevelError, entries[0].Level)
+	assert.Equal(t, "create user", entries[0].Field("scenario"))
+	assert.Contains(t, entries[0].Field("body"), "curl -X 'POST'")
+
+	entries = nil
+	ctx.WithCurlOnFailure(false).printCurlCommands()
+	assert.Empty(t, entries)
+
+	assert.Nil(t, ctx.ISetHeaderWithValue("Host", "api.example.com"))
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/users"))
+	assert.Equal(t, "curl -X 'GET' '"+ts.URL+"/users' \\\n"+
+		"  -H 'Content-Type: application/json' \\\n"+
+		"  -H 'Host: api.example.com' \\\n"+
+		"  -H 'X-Name: O'\\''Brien'", ctx.LastRequestAsCurl())
+
+	assert.Nil(t, ctx.ISetHeaderWithValue("Authorization", "Bearer token"))
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/users"))
+	assert.Contains(t, ctx.LastRequestAsCurl(), "-H 'Authorization: ****'")
+	assert.NotContains(t, ctx.LastRequestAsCurl(), "Bearer token")
+}
+
+func TestApiContext_WriteHARFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "har")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=abc")
+		_, _ = w.Write([]byte(`{"id": 1}`))
+	}))
+	defer ts.Close()
+
+	path := filepath.Join(dir, "reports", "suite.har")
+	ctx := New(ts.URL).WithHARFile(path)
+	assert.Error(t, New(ts.URL).WriteHARFile())
+
+	ctx.reset(&godog.Scenario{Name: "Create user"})
+	assert.Nil(t, ctx.ISetQueryParamWithValue("page", "2"))
+	assert.Nil(t, ctx.ISetHeaderWithValue("Cookie", "session=abc"))
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/users"))
+	ctx.reset(&godog.Scenario{Name: "Delete user"})
+	assert.Nil(t, ctx.ISendRequestToWithBody("DELETE", "/users/1", &godog.DocString{Content: "{}"}))
+	assert.Nil(t, ctx.WriteHARFile())
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var har struct {
+		Log harLog `json:"log"`
+	}
+	assert.Nil(t, json.Unmarshal(contents, &har))
+	assert.Equal(t, "1.2", har.Log.Version)
+	assert.Len(t, har.Log.Entries, 2)
+
+	first := har.Log.Entries[0]
+	assert.Equal(t, "GET", first.Request.Method)
+	assert.Equal(t, ts.URL+"/users?page=2", first.Request.URL)
+	assert.Equal(t, []harNameValue{{Name: "page", Value: "2"}}, first.Request.QueryString)
+	assert.Nil(t, first.Request.PostData)
+	assert.Equal(t, 200, first.Response.Status)
+	assert.Equal(t, "application/json", first.Response.Content.MimeType)
+	assert.Equal(t, `{"id": 1}`, first.Response.Content.Text)
+	assert.Equal(t, "Create user", first.Comment)
+	assert.Contains(t, first.Request.Headers, harNameValue{Name: "Cookie", Value: "****"})
+	assert.Contains(t, first.Response.Headers, harNameValue{Name: "Set-Cookie", Value: "****"})
+	assert.NotContains(t, string(contents), "session=abc")
+
+	second := har.Log.Entries[1]
+	assert.Equal(t, "DELETE", second.Request.Method)
+	assert.Equal(t, "{}", second.Request.PostData.Text)
+	assert.Equal(t, "Delete user", second.Comment)
+}
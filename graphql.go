@@ -0,0 +1,182 @@
+package apicontext
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/cucumber/godog"
+)
+
+// ISetGraphQLVariablesTo Sets the variables sent with the next GraphQL query, from a JSON DocString.
+func (ctx *ApiContext) ISetGraphQLVariablesTo(variables *godog.DocString) error {
+	content := ctx.ReplaceScopeVariables(variables.Content)
+
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(content), &data); err != nil {
+		return fmt.Errorf("the GraphQL variables are not a valid json object: %v", err)
+	}
+
+	ctx.graphQLVariables = data
+	return nil
+}
+
+// ISendAGraphQLQueryTo Sends the GraphQL query from the DocString as a POST request to the specified endpoint.
+func (ctx *ApiContext) ISendAGraphQLQueryTo(uri string, query *godog.DocString) error {
+	return ctx.sendGraphQLQuery(uri, query.Content, "")
+}
+
+// ISendAGraphQLQueryToWithOperationName Sends the GraphQL query from the DocString, executing the specified operation.
+func (ctx *ApiContext) ISendAGraphQLQueryToWithOperationName(uri string, operationName string, query *godog.DocString) error {
+	return ctx.sendGraphQLQuery(uri, query.Content, operationName)
+}
+
+// TheGraphQLResponseShouldHaveNoErrors Checks that the "errors" field of the GraphQL response is absent or empty.
+func (ctx *ApiContext) TheGraphQLResponseShouldHaveNoErrors() error {
+	value, found, err := ctx.lookupJSONPath("jsonpath:$.errors")
+
+	if err != nil {
+		return err
+	}
+
+	if !found || value == nil {
+		return nil
+	}
+
+	if errs, ok := value.([]interface{}); ok && len(errs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("expected the GraphQL response to have no errors, but it has %s", jsonValueToJSON(value))
+}
+
+// TheGraphQLErrorAtIndexShouldHaveMessage Checks the message of the GraphQL error at the specified index.
+func (ctx *ApiContext) TheGraphQLErrorAtIndexShouldHaveMessage(index int, expectedMessage string) error {
+	value, found, err := ctx.lookupJSONPath("jsonpath:$.errors")
+
+	if err != nil {
+		return fmt.Errorf("cannot read the GraphQL errors: %v", err)
+	}
+
+	if !found || value == nil {
+		return fmt.Errorf("the GraphQL response has no errors")
+	}
+
+	errs, ok := value.([]interface{})
+	if !ok {
+		return fmt.Errorf("expected the GraphQL errors to be an array, but it is %s", jsonValueToJSON(value))
+	}
+
+	if index < 0 || index >= len(errs) {
+		return fmt.Errorf("the GraphQL response has no error at index %d", index)
+	}
+
+	gqlErr, ok := errs[index].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("expected the GraphQL error at index %d to be an object, but it is %s", index, jsonValueToJSON(errs[index]))
+	}
+
+	message, ok := gqlErr["message"].(string)
+	if !ok {
+		return fmt.Errorf("expected the GraphQL error at index %d to have a string message, but it is %s", index, jsonValueToJSON(gqlErr["message"]))
+	}
+
+	expectedMessage = ctx.ReplaceScopeVariables(expectedMessage)
+
+	if message != expectedMessage {
+		return fmt.Errorf("expected the GraphQL error at index %d to have message %s, but it is %s", index, expectedMessage, message)
+	}
+
+	return nil
+}
+
+// TheGraphQLPathShouldHaveValue Validates the value at the specified path of the GraphQL response data.
+func (ctx *ApiContext) TheGraphQLPathShouldHaveValue(pathExpr string, expectedValue string) error {
+	return ctx.TheJSONPathShouldHaveValue(ctx.graphQLDataPath(pathExpr), expectedValue)
+}
+
+// TheGraphQLPathShouldMatch Checks if the value at the specified path of the GraphQL response data matches the pattern.
+func (ctx *ApiContext) TheGraphQLPathShouldMatch(pathExpr string, pattern string) error {
+	return ctx.TheJSONPathShouldMatch(ctx.graphQLDataPath(pathExpr), pattern)
+}
+
+// TheGraphQLPathShouldBePresent Checks if the specified path exists in the GraphQL response data.
+func (ctx *ApiContext) TheGraphQLPathShouldBePresent(pathExpr string) error {
+	return ctx.TheJSONPathShouldBePresent(ctx.graphQLDataPath(pathExpr))
+}
+
+// TheGraphQLPathShouldNotBePresent Checks that the specified path does not exist in the GraphQL response data.
+func (ctx *ApiContext) TheGraphQLPathShouldNotBePresent(pathExpr string) error {
+	return ctx.TheJSONPathShouldNotBePresent(ctx.graphQLDataPath(pathExpr))
+}
+
+// TheGraphQLPathHaveCount Validates the length of the array at the specified path of the GraphQL response data.
+func (ctx *ApiContext) TheGraphQLPathHaveCount(pathExpr string, expectedCount int) error {
+	return ctx.TheJSONPathHaveCount(ctx.graphQLDataPath(pathExpr), expectedCount)
+}
+
+// StoreGraphQLPathValue Store value from the GraphQL response data to scope map.
+func (ctx *ApiContext) StoreGraphQLPathValue(pathExpr string, scopeKeyName string) error {
+	return ctx.StoreJsonPathValue(ctx.graphQLDataPath(pathExpr), scopeKeyName)
+}
+
+// sendGraphQLQuery builds the GraphQL request body and sends it with ISendRequestToWithBody.
+func (ctx *ApiContext) sendGraphQLQuery(uri string, query string, operationName string) error {
+	payload := map[string]interface{}{
+		"query": query,
+	}
+
+	if ctx.graphQLVariables != nil {
+		payload["variables"] = ctx.graphQLVariables
+	}
+
+	if operationName != "" {
+		payload["operationName"] = operationName
+	}
+
+	// the variables only apply to the next query.
+	ctx.graphQLVariables = nil
+
+	body, err := json.Marshal(payload)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.ISendRequestToWithBody(http.MethodPost, uri, &godog.DocString{
+		Content:   string(body),
+		MediaType: "application/json",
+	})
+}
+
+// graphQLDataPath roots the path at the "data" field of the GraphQL response, using the path dialect.
+func (ctx *ApiContext) graphQLDataPath(pathExpr string) string {
+	dialect, expr := ctx.resolvePathDialect(pathExpr)
+	prefix := ""
+	if expr != pathExpr {
+		prefix = string(dialect) + ":"
+	}
+
+	switch dialect {
+	case JSONPointerDialect:
+		return prefix + "/data" + expr
+	case JMESPathDialect:
+		if expr == "" || expr == "@" {
+			return prefix + "data"
+		}
+		if strings.HasPrefix(expr, "[") {
+			return prefix + "data" + expr
+		}
+		return prefix + "data." + expr
+	default:
+		expr = strings.TrimPrefix(strings.TrimPrefix(expr, "$"), ".")
+		if expr == "" {
+			return prefix + "$.data"
+		}
+		if strings.HasPrefix(expr, "[") {
+			return prefix + "$.data" + expr
+		}
+		return prefix + "$.data." + expr
+	}
+}
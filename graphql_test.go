@@ -0,0 +1,121 @@
+package apicontext
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupGraphQLTestServer(t *testing.T, received *map[string]interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		if err := json.NewDecoder(r.Body).Decode(received); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if (*received)["operationName"] == "Broken" {
+			_, _ = w.Write([]byte(`{"data": null, "errors": [{"message": "Cannot query field \"foo\""}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": {"user": {"id": "1", "name": "Bruno", "roles": ["admin", "dev"]}}}`))
+	}))
+}
+
+func TestApiContext_ISendAGraphQLQueryTo(t *testing.T) {
+	var received map[string]interface{}
+	ts := setupGraphQLTestServer(t, &received)
+	defer ts.Close()
+
+	ctx := setupTestContext().
+		WithBaseURL(ts.URL).
+		WithDebug(false)
+
+	assert.Nil(t, ctx.StoreScopeData("id", "1"))
+	assert.Nil(t, ctx.ISetGraphQLVariablesTo(&godog.DocString{Content: "{\"id\": \"`##id`\"}"}))
+	assert.Nil(t, ctx.ISendAGraphQLQueryToWithOperationName("/graphql", "GetUser", &godog.DocString{
+		Content: "query GetUser($id: ID!) { user(id: $id) { id name roles } }",
+	}))
+
+	assert.Equal(t, "GetUser", received["operationName"])
+	assert.Equal(t, map[string]interface{}{"id": "1"}, received["variables"])
+	assert.Contains(t, received["query"], "query GetUser")
+
+	assert.Nil(t, ctx.TheResponseCodeShouldBe(200))
+	assert.Nil(t, ctx.TheGraphQLResponseShouldHaveNoErrors())
+	assert.Error(t, ctx.TheGraphQLErrorAtIndexShouldHaveMessage(0, "error"))
+	assert.Nil(t, ctx.TheGraphQLPathShouldHaveValue("user.name", "Bruno"))
+	assert.Nil(t, ctx.TheGraphQLPathShouldHaveValue("$.user.id", "1"))
+	assert.Nil(t, ctx.TheGraphQLPathShouldMatch("user.name", "^B"))
+	assert.Nil(t, ctx.TheGraphQLPathShouldBePresent("user"))
+	assert.Nil(t, ctx.TheGraphQLPathShouldNotBePresent("account"))
+	assert.Nil(t, ctx.TheGraphQLPathHaveCount("user.roles", 2))
+	assert.Nil(t, ctx.TheGraphQLPathShouldHaveValue("pointer:/user/roles/1", "dev"))
+	assert.Nil(t, ctx.StoreGraphQLPathValue("user.name", "name"))
+	assert.Nil(t, ctx.TheScopeVariableShouldHaveValue("name", "Bruno"))
+
+	received = nil
+	assert.Nil(t, ctx.ISendAGraphQLQueryTo("/graphql", &godog.DocString{Content: "query { user(id: 1) { id } }"}))
+	assert.NotContains(t, received, "variables")
+}
+
+func TestApiContext_TheGraphQLResponseShouldHaveErrors(t *testing.T) {
+	var received map[string]interface{}
+	ts := setupGraphQLTestServer(t, &received)
+	defer ts.Close()
+
+	ctx := setupTestContext().
+		WithBaseURL(ts.URL).
+		WithDebug(false)
+
+	assert.Nil(t, ctx.ISendAGraphQLQueryToWithOperationName("/graphql", "Broken", &godog.DocString{
+		Content: "query Broken { foo }",
+	}))
+
+	assert.Nil(t, received["variables"])
+	assert.Error(t, ctx.TheGraphQLResponseShouldHaveNoErrors())
+	assert.Nil(t, ctx.TheGraphQLErrorAtIndexShouldHaveMessage(0, "Cannot query field \"foo\""))
+	assert.EqualError(t, ctx.TheGraphQLErrorAtIndexShouldHaveMessage(1, "Cannot query field \"foo\""), "the GraphQL response has no error at index 1")
+	assert.EqualError(t, ctx.TheGraphQLErrorAtIndexShouldHaveMessage(0, "other"), "expected the GraphQL error at index 0 to have message other, but it is Cannot query field \"foo\"")
+}
+
+func TestApiContext_TheGraphQLErrorAtIndexShouldHaveMessageWithInvalidErrors(t *testing.T) {
+	ctx, closeServer := setupResponseTestContext(t, "application/json", `{"data": {"user": null}}`)
+	defer closeServer()
+	assert.EqualError(t, ctx.TheGraphQLErrorAtIndexShouldHaveMessage(0, "error"), "the GraphQL response has no errors")
+
+	ctx, closeServer = setupResponseTestContext(t, "application/json", `{"errors": [{"message": 42}]}`)
+	defer closeServer()
+	assert.EqualError(t, ctx.TheGraphQLErrorAtIndexShouldHaveMessage(0, "42"), "expected the GraphQL error at index 0 to have a string message, but it is 42")
+
+	ctx, closeServer = setupResponseTestContext(t, "application/json", `{"errors": `)
+	defer closeServer()
+	err := ctx.TheGraphQLErrorAtIndexShouldHaveMessage(0, "error")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "cannot read the GraphQL errors")
+	}
+}
+
+func TestApiContext_ISetGraphQLVariablesToInvalidJSON(t *testing.T) {
+	ctx := setupTestContext()
+	assert.Error(t, ctx.ISetGraphQLVariablesTo(&godog.DocString{Content: "[1, 2"}))
+}
+
+func TestApiContext_graphQLDataPath(t *testing.T) {
+	ctx := setupTestContext()
+
+	assert.Equal(t, "$.data", ctx.graphQLDataPath("$"))
+	assert.Equal(t, "$.data.user.id", ctx.graphQLDataPath("user.id"))
+	assert.Equal(t, "$.data.user.id", ctx.graphQLDataPath("$.user.id"))
+	assert.Equal(t, "$.data[0]", ctx.graphQLDataPath("$[0]"))
+	assert.Equal(t, "pointer:/data/user", ctx.graphQLDataPath("pointer:/user"))
+	assert.Equal(t, "jmes:data.user", ctx.graphQLDataPath("jmes:user"))
+	assert.Equal(t, "jmes:data[0].name", ctx.graphQLDataPath("jmes:[0].name"))
+}
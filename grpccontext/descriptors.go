@@ -0,0 +1,220 @@
+package grpccontext
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// findMethod returns the descriptor of the method, like "pkg.Service/Method", loading the service descriptors
+// from the descriptor sets or using server reflection.
+func (ctx *GrpcContext) findMethod(callCtx context.Context, method string) (protoreflect.MethodDescriptor, error) {
+	method = strings.TrimPrefix(method, "/")
+	i := strings.LastIndex(method, "/")
+
+	if i < 0 {
+		return nil, fmt.Errorf("invalid gRPC method %s, expected the format pkg.Service/Method", method)
+	}
+
+	serviceName, methodName := method[:i], method[i+1:]
+
+	if ctx.files == nil {
+		ctx.files = &protoregistry.Files{}
+
+		if len(ctx.descriptorSets) > 0 {
+			if err := ctx.loadDescriptorSets(); err != nil {
+				ctx.files = nil
+				return nil, err
+			}
+		}
+	}
+
+	if _, err := ctx.files.FindDescriptorByName(protoreflect.FullName(serviceName)); err != nil && len(ctx.descriptorSets) == 0 {
+		if err := ctx.loadFromReflection(callCtx, serviceName); err != nil {
+			return nil, err
+		}
+	}
+
+	d, err := ctx.files.FindDescriptorByName(protoreflect.FullName(serviceName))
+
+	if err != nil {
+		return nil, fmt.Errorf("the gRPC service %s was not found: %v", serviceName, err)
+	}
+
+	service, ok := d.(protoreflect.ServiceDescriptor)
+
+	if !ok {
+		return nil, fmt.Errorf("%s is not a gRPC service", serviceName)
+	}
+
+	md := service.Methods().ByName(protoreflect.Name(methodName))
+
+	if md == nil {
+		return nil, fmt.Errorf("the gRPC service %s has no method %s", serviceName, methodName)
+	}
+
+	return md, nil
+}
+
+// loadDescriptorSets registers the files of the configured descriptor sets.
+func (ctx *GrpcContext) loadDescriptorSets() error {
+	protos := map[string]*descriptorpb.FileDescriptorProto{}
+
+	for _, path := range ctx.descriptorSets {
+		contents, err := ioutil.ReadFile(path)
+
+		if err != nil {
+			return fmt.Errorf("cannot open descriptor set file: %v", err)
+		}
+
+		set := &descriptorpb.FileDescriptorSet{}
+		if err := proto.Unmarshal(contents, set); err != nil {
+			return fmt.Errorf("invalid descriptor set file %s: %v", path, err)
+		}
+
+		for _, fd := range set.GetFile() {
+			protos[fd.GetName()] = fd
+		}
+	}
+
+	for name := range protos {
+		if err := registerFile(ctx.files, protos, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadFromReflection registers the file defining the symbol, and its dependencies, using the server reflection service.
+func (ctx *GrpcContext) loadFromReflection(callCtx context.Context, symbol string) error {
+	stream, err := rpb.NewServerReflectionClient(ctx.conn).ServerReflectionInfo(callCtx)
+
+	if err != nil {
+		return fmt.Errorf("cannot describe the gRPC service %s using server reflection: %v", symbol, err)
+	}
+
+	defer func() {
+		_ = stream.CloseSend()
+	}()
+
+	protos := map[string]*descriptorpb.FileDescriptorProto{}
+	request := &rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: symbol},
+	}
+	pending := []*rpb.ServerReflectionRequest{request}
+	var root string
+
+	for len(pending) > 0 {
+		if err := stream.Send(pending[0]); err != nil {
+			return fmt.Errorf("cannot describe the gRPC service %s using server reflection: %v", symbol, err)
+		}
+		pending = pending[1:]
+
+		resp, err := stream.Recv()
+
+		if err != nil {
+			return fmt.Errorf("cannot describe the gRPC service %s using server reflection: %v", symbol, err)
+		}
+
+		if errResp := resp.GetErrorResponse(); errResp != nil {
+			return fmt.Errorf("cannot describe the gRPC service %s using server reflection: %s", symbol, errResp.GetErrorMessage())
+		}
+
+		for _, b := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			fd := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(b, fd); err != nil {
+				return fmt.Errorf("invalid file descriptor returned by server reflection: %v", err)
+			}
+
+			if root == "" {
+				root = fd.GetName()
+			}
+			protos[fd.GetName()] = fd
+		}
+
+		// request the dependencies not returned by the server and unknown to this program.
+		for _, fd := range protos {
+			for _, dep := range fd.GetDependency() {
+				if _, ok := protos[dep]; ok {
+					continue
+				}
+				if _, err := protoregistry.GlobalFiles.FindFileByPath(dep); err == nil {
+					continue
+				}
+				protos[dep] = nil
+				pending = append(pending, &rpb.ServerReflectionRequest{
+					MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: dep},
+				})
+			}
+		}
+	}
+
+	return registerFile(ctx.files, protos, root)
+}
+
+// registerFile builds the file descriptor from its proto, registering its dependencies first.
+// Dependencies missing from protos are looked up in the files linked to this program, like the well known types.
+func registerFile(files *protoregistry.Files, protos map[string]*descriptorpb.FileDescriptorProto, name string) error {
+	if _, err := files.FindFileByPath(name); err == nil {
+		return nil
+	}
+
+	fdp := protos[name]
+
+	if fdp == nil {
+		fd, err := protoregistry.GlobalFiles.FindFileByPath(name)
+
+		if err != nil {
+			return fmt.Errorf("the proto file %s was not found", name)
+		}
+
+		return files.RegisterFile(fd)
+	}
+
+	for _, dep := range fdp.GetDependency() {
+		if err := registerFile(files, protos, dep); err != nil {
+			return err
+		}
+	}
+
+	fd, err := protodesc.NewFile(fdp, files)
+
+	if err != nil {
+		return fmt.Errorf("invalid proto file %s: %v", name, err)
+	}
+
+	return files.RegisterFile(fd)
+}
+
+// statusToJSON encodes an error status like the gRPC-JSON gateways do. Details of unknown types only include their type.
+func statusToJSON(st *status.Status) ([]byte, error) {
+	details := make([]json.RawMessage, 0)
+
+	for _, detail := range st.Proto().GetDetails() {
+		b, err := protojson.Marshal(detail)
+
+		if err != nil {
+			b, _ = json.Marshal(map[string]string{"@type": detail.GetTypeUrl()})
+		}
+
+		details = append(details, b)
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"code":    int(st.Code()),
+		"message": st.Message(),
+		"details": details,
+	})
+}
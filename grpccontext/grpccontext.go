@@ -0,0 +1,286 @@
+// Package grpccontext defines step definitions for calling gRPC services from Godog scenarios.
+// The responses are encoded as JSON and handed to an apicontext.ApiContext, so its json path, scope and schema steps
+// can be used to validate them.
+package grpccontext
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	apicontext "github.com/brpaz/godog-api-context"
+	"github.com/cucumber/godog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// The default timeout of each gRPC call.
+const defaultTimeout = 10 * time.Second
+
+// GrpcContext main struct
+type GrpcContext struct {
+	target         string
+	api            *apicontext.ApiContext
+	dialOptions    []grpc.DialOption
+	descriptorSets []string
+	timeout        time.Duration
+	conn           *grpc.ClientConn
+	files          *protoregistry.Files
+	metadata       metadata.MD
+	lastStatus     *status.Status
+}
+
+// New Creates a new instance of the gRPC context, calling the server at target.
+// The responses are stored as the last response of api, which must also be registered in the scenario.
+func New(target string, api *apicontext.ApiContext) *GrpcContext {
+	return &GrpcContext{
+		target:      target,
+		api:         api,
+		dialOptions: []grpc.DialOption{grpc.WithInsecure()},
+		timeout:     defaultTimeout,
+		metadata:    metadata.MD{},
+	}
+}
+
+// WithDialOptions Configures the options used to connect to the server, like the transport credentials.
+// By default the connection is insecure.
+func (ctx *GrpcContext) WithDialOptions(opts ...grpc.DialOption) *GrpcContext {
+	ctx.dialOptions = opts
+	return ctx
+}
+
+// WithDescriptorSets Specifies the descriptor set files, generated with "protoc --descriptor_set_out --include_imports",
+// used to describe the services. When no descriptor set is specified, the services are described using server reflection.
+func (ctx *GrpcContext) WithDescriptorSets(paths ...string) *GrpcContext {
+	ctx.descriptorSets = paths
+	return ctx
+}
+
+// WithTimeout Configures the timeout of each gRPC call.
+func (ctx *GrpcContext) WithTimeout(timeout time.Duration) *GrpcContext {
+	ctx.timeout = timeout
+	return ctx
+}
+
+// InitializeScenario this function should be called when starting the Test suite, to register the available steps.
+func (ctx *GrpcContext) InitializeScenario(s *godog.ScenarioContext) {
+	s.BeforeScenario(ctx.reset)
+
+	s.Step(`^I set gRPC metadata "([^"]*)" with value "([^"]*)"$`, ctx.ISetGRPCMetadataWithValue)
+	s.Step(`^I call gRPC method "([^"]*)" with:$`, ctx.ICallGRPCMethodWith)
+	s.Step(`^I call gRPC method "([^"]*)"$`, ctx.ICallGRPCMethod)
+	s.Step(`^The gRPC status code should be "([^"]*)"$`, ctx.TheGRPCStatusCodeShouldBe)
+	s.Step(`^The gRPC status message should be "([^"]*)"$`, ctx.TheGRPCStatusMessageShouldBe)
+	s.Step(`^The gRPC status details should contain "([^"]*)"$`, ctx.TheGRPCStatusDetailsShouldContain)
+}
+
+// Close closes the connection to the server. It should be called when the Test suite finishes.
+func (ctx *GrpcContext) Close() error {
+	if ctx.conn == nil {
+		return nil
+	}
+
+	err := ctx.conn.Close()
+	ctx.conn = nil
+	return err
+}
+
+// reset Reset the internal state of the gRPC context
+func (ctx *GrpcContext) reset(*godog.Scenario) {
+	ctx.metadata = metadata.MD{}
+	ctx.lastStatus = nil
+}
+
+// ISetGRPCMetadataWithValue Adds a metadata entry to the next gRPC calls.
+func (ctx *GrpcContext) ISetGRPCMetadataWithValue(name string, value string) error {
+	ctx.metadata.Set(name, ctx.api.ReplaceScopeVariables(value))
+	return nil
+}
+
+// ICallGRPCMethod Calls the unary gRPC method, like "pkg.Service/Method", with an empty message.
+func (ctx *GrpcContext) ICallGRPCMethod(method string) error {
+	return ctx.call(method, "{}")
+}
+
+// ICallGRPCMethodWith Calls the unary gRPC method, like "pkg.Service/Method", with the message from the JSON DocString.
+func (ctx *GrpcContext) ICallGRPCMethodWith(method string, body *godog.DocString) error {
+	return ctx.call(method, ctx.api.ReplaceScopeVariables(body.Content))
+}
+
+// TheGRPCStatusCodeShouldBe Checks the status code of the last call, like "OK", "NotFound" or "NOT_FOUND".
+func (ctx *GrpcContext) TheGRPCStatusCodeShouldBe(expectedCode string) error {
+	if ctx.lastStatus == nil {
+		return fmt.Errorf("no gRPC method was called")
+	}
+
+	if normalizeCode(ctx.lastStatus.Code().String()) != normalizeCode(expectedCode) {
+		return fmt.Errorf("expected gRPC status code to be %s, but actual is %s.\n Status message: %s", expectedCode, ctx.lastStatus.Code(), ctx.lastStatus.Message())
+	}
+
+	return nil
+}
+
+// TheGRPCStatusMessageShouldBe Checks the status message of the last call.
+func (ctx *GrpcContext) TheGRPCStatusMessageShouldBe(expectedMessage string) error {
+	if ctx.lastStatus == nil {
+		return fmt.Errorf("no gRPC method was called")
+	}
+
+	expectedMessage = ctx.api.ReplaceScopeVariables(expectedMessage)
+
+	if ctx.lastStatus.Message() != expectedMessage {
+		return fmt.Errorf("expected gRPC status message to be %s, but actual is %s", expectedMessage, ctx.lastStatus.Message())
+	}
+
+	return nil
+}
+
+// TheGRPCStatusDetailsShouldContain Checks that the status of the last call has a detail of the specified type, like "google.rpc.BadRequest".
+func (ctx *GrpcContext) TheGRPCStatusDetailsShouldContain(typeName string) error {
+	if ctx.lastStatus == nil {
+		return fmt.Errorf("no gRPC method was called")
+	}
+
+	var found []string
+	for _, detail := range ctx.lastStatus.Proto().GetDetails() {
+		name := detail.GetTypeUrl()[strings.LastIndex(detail.GetTypeUrl(), "/")+1:]
+		if name == typeName {
+			return nil
+		}
+		found = append(found, name)
+	}
+
+	return fmt.Errorf("expected gRPC status details to contain %s, but found %v", typeName, found)
+}
+
+// call invokes the unary method with the JSON encoded request and stores the JSON encoded response in the api context.
+func (ctx *GrpcContext) call(method string, body string) error {
+	conn, err := ctx.connection()
+
+	if err != nil {
+		return err
+	}
+
+	callCtx, cancel := context.WithTimeout(context.Background(), ctx.timeout)
+	defer cancel()
+
+	md, err := ctx.findMethod(callCtx, method)
+
+	if err != nil {
+		return err
+	}
+
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		return fmt.Errorf("the gRPC method %s is a streaming method, only unary methods are supported", method)
+	}
+
+	req := dynamicpb.NewMessage(md.Input())
+	if err := protojson.Unmarshal([]byte(body), req); err != nil {
+		return fmt.Errorf("cannot encode the message for %s: %v", md.Input().FullName(), err)
+	}
+
+	resp := dynamicpb.NewMessage(md.Output())
+	var header, trailer metadata.MD
+
+	fullMethod := fmt.Sprintf("/%s/%s", md.Parent().FullName(), md.Name())
+	callCtx = metadata.NewOutgoingContext(callCtx, ctx.metadata.Copy())
+	err = conn.Invoke(callCtx, fullMethod, req, resp, grpc.Header(&header), grpc.Trailer(&trailer))
+
+	st := status.New(codes.OK, "")
+	if err != nil {
+		st = status.Convert(err)
+	}
+	ctx.lastStatus = st
+
+	var respBody []byte
+	if st.Code() == codes.OK {
+		respBody, err = protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(resp)
+	} else {
+		respBody, err = statusToJSON(st)
+	}
+
+	if err != nil {
+		return fmt.Errorf("cannot encode the response of %s as json: %v", fullMethod, err)
+	}
+
+	httpResp := &http.Response{
+		StatusCode: httpStatusFromCode(st.Code()),
+		Header:     http.Header{},
+	}
+	httpResp.Header.Set("Content-Type", "application/json")
+	httpResp.Header.Set("Grpc-Status", fmt.Sprintf("%d", st.Code()))
+	httpResp.Header.Set("Grpc-Message", st.Message())
+	for _, md := range []metadata.MD{header, trailer} {
+		for name, values := range md {
+			for _, value := range values {
+				httpResp.Header.Add(name, value)
+			}
+		}
+	}
+
+	ctx.api.SetLastResponse(&apicontext.ApiResponse{
+		StatusCode:  httpResp.StatusCode,
+		Body:        string(respBody),
+		ResponseObj: httpResp,
+	})
+
+	return nil
+}
+
+// connection returns the connection to the server, connecting on the first call.
+func (ctx *GrpcContext) connection() (*grpc.ClientConn, error) {
+	if ctx.conn != nil {
+		return ctx.conn, nil
+	}
+
+	conn, err := grpc.Dial(ctx.target, ctx.dialOptions...)
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to gRPC server %s: %v", ctx.target, err)
+	}
+
+	ctx.conn = conn
+	return conn, nil
+}
+
+// normalizeCode allows codes to be written as in the proto definition (NOT_FOUND) or in Go (NotFound).
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.Replace(code, "_", "", -1))
+}
+
+// httpStatusFromCode maps the gRPC status codes to HTTP status codes, like the gRPC-JSON gateways do.
+func httpStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
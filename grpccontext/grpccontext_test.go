@@ -0,0 +1,168 @@
+package grpccontext
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	apicontext "github.com/brpaz/godog-api-context"
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func setupTestServer(t *testing.T, withReflection bool) (string, func()) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := grpc.NewServer()
+	hs := health.NewServer()
+	hs.SetServingStatus("orders", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(s, hs)
+
+	if withReflection {
+		reflection.Register(s)
+	}
+
+	go func() {
+		_ = s.Serve(lis)
+	}()
+
+	return lis.Addr().String(), s.Stop
+}
+
+func setupTestContext(target string) (*GrpcContext, *apicontext.ApiContext) {
+	api := apicontext.New("")
+	return New(target, api), api
+}
+
+func TestGrpcContext_ICallGRPCMethodWithReflection(t *testing.T) {
+	target, stop := setupTestServer(t, true)
+	defer stop()
+
+	ctx, api := setupTestContext(target)
+	defer ctx.Close()
+
+	assert.Nil(t, ctx.ICallGRPCMethod("grpc.health.v1.Health/Check"))
+	assert.Nil(t, ctx.TheGRPCStatusCodeShouldBe("OK"))
+	assert.Nil(t, api.TheResponseCodeShouldBe(200))
+	assert.Nil(t, api.TheJSONPathShouldHaveValue("$.status", "SERVING"))
+
+	assert.Nil(t, api.StoreScopeData("service", "orders"))
+	assert.Nil(t, ctx.ICallGRPCMethodWith("/grpc.health.v1.Health/Check", &godog.DocString{
+		Content: "{\"service\": \"`##service`\"}",
+	}))
+	assert.Nil(t, api.TheJSONPathShouldHaveValue("$.status", "NOT_SERVING"))
+
+	assert.Nil(t, ctx.ICallGRPCMethodWith("grpc.health.v1.Health/Check", &godog.DocString{
+		Content: `{"service": "missing"}`,
+	}))
+	assert.Nil(t, ctx.TheGRPCStatusCodeShouldBe("NOT_FOUND"))
+	assert.Nil(t, ctx.TheGRPCStatusCodeShouldBe("NotFound"))
+	assert.Error(t, ctx.TheGRPCStatusCodeShouldBe("OK"))
+	assert.Nil(t, ctx.TheGRPCStatusMessageShouldBe("unknown service"))
+	assert.Error(t, ctx.TheGRPCStatusDetailsShouldContain("google.rpc.BadRequest"))
+	assert.Nil(t, api.TheResponseCodeShouldBe(404))
+	assert.Nil(t, api.TheJSONPathShouldHaveValue("$.code", "5"))
+	assert.Nil(t, api.TheJSONPathShouldHaveValue("$.message", "unknown service"))
+}
+
+func TestGrpcContext_ICallGRPCMethodErrors(t *testing.T) {
+	target, stop := setupTestServer(t, true)
+	defer stop()
+
+	ctx, _ := setupTestContext(target)
+	defer ctx.Close()
+
+	assert.Error(t, ctx.TheGRPCStatusCodeShouldBe("OK"))
+	assert.Error(t, ctx.ICallGRPCMethod("grpc.health.v1.Health"))
+	assert.Error(t, ctx.ICallGRPCMethod("grpc.health.v1.Missing/Check"))
+	assert.Error(t, ctx.ICallGRPCMethod("grpc.health.v1.Health/Missing"))
+	assert.Error(t, ctx.ICallGRPCMethod("grpc.health.v1.Health/Watch"))
+	assert.Error(t, ctx.ICallGRPCMethodWith("grpc.health.v1.Health/Check", &godog.DocString{
+		Content: `{"unknown": "field"}`,
+	}))
+}
+
+func TestGrpcContext_ICallGRPCMethodWithDescriptorSet(t *testing.T) {
+	target, stop := setupTestServer(t, false)
+	defer stop()
+
+	dir, err := ioutil.TempDir("", "grpccontext")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	set := &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{protodesc.ToFileDescriptorProto(healthpb.File_grpc_health_v1_health_proto)},
+	}
+	b, err := proto.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "health.protoset")
+	if err := ioutil.WriteFile(path, b, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, api := setupTestContext(target)
+	ctx.WithDescriptorSets(path)
+	defer ctx.Close()
+
+	assert.Nil(t, ctx.ICallGRPCMethod("grpc.health.v1.Health/Check"))
+	assert.Nil(t, api.TheJSONPathShouldHaveValue("$.status", "SERVING"))
+
+	noReflection, _ := setupTestContext(target)
+	defer noReflection.Close()
+	assert.Error(t, noReflection.ICallGRPCMethod("grpc.health.v1.Health/Check"))
+}
+
+func TestGrpcContext_ISetGRPCMetadataWithValue(t *testing.T) {
+	var received []string
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		received = md.Get("authorization")
+		_ = grpc.SetHeader(ctx, metadata.Pairs("x-request-id", "123"))
+		return handler(ctx, req)
+	}))
+	healthpb.RegisterHealthServer(s, health.NewServer())
+	reflection.Register(s)
+	go func() {
+		_ = s.Serve(lis)
+	}()
+	defer s.Stop()
+
+	ctx, api := setupTestContext(lis.Addr().String())
+	defer ctx.Close()
+
+	assert.Nil(t, api.StoreScopeData("token", "secret"))
+	assert.Nil(t, ctx.ISetGRPCMetadataWithValue("Authorization", "Bearer `##token`"))
+	assert.Nil(t, ctx.ICallGRPCMethod("grpc.health.v1.Health/Check"))
+	assert.Equal(t, []string{"Bearer secret"}, received)
+	assert.Nil(t, api.TheResponseHeaderShouldHaveValue("X-Request-Id", "123"))
+
+	ctx.reset(nil)
+	assert.Nil(t, ctx.ICallGRPCMethod("grpc.health.v1.Health/Check"))
+	assert.Empty(t, received)
+}
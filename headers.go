@@ -0,0 +1,85 @@
+package apicontext
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// WithDefaultHeaders Configures headers sent in every request of every scenario. The scenarios can change or remove them.
+func (ctx *ApiContext) WithDefaultHeaders(headers map[string]string) *ApiContext {
+	for name, value := range headers {
+		ctx.defaultHeaders.Set(name, value)
+		ctx.headers.Set(name, value)
+	}
+
+	return ctx
+}
+
+// IAddHeaderWithValue Adds a value to a request header, keeping its previous values.
+func (ctx *ApiContext) IAddHeaderWithValue(name string, value string) error {
+	delete(ctx.removedHeaders, http.CanonicalHeaderKey(name))
+	ctx.scenarioHeaders[http.CanonicalHeaderKey(name)] = true
+	ctx.headers.Add(name, ctx.ReplaceScopeVariables(value))
+	return nil
+}
+
+// IRemoveHeader Removes a request header, including the default headers, the service headers
+// and the User-Agent header added by the HTTP client.
+func (ctx *ApiContext) IRemoveHeader(name string) error {
+	ctx.headers.Del(name)
+	ctx.removedHeaders[http.CanonicalHeaderKey(name)] = true
+	return nil
+}
+
+// hasHeader checks if the request header was set, ignoring the case of its name.
+func (ctx *ApiContext) hasHeader(name string) bool {
+	for header := range ctx.headers {
+		if strings.EqualFold(header, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// defaultHeadersCopy returns a copy of the headers sent in every request: the profile headers and the default headers.
+func (ctx *ApiContext) defaultHeadersCopy() http.Header {
+	headers := ctx.profileHeaders.Clone()
+	if headers == nil {
+		headers = http.Header{}
+	}
+
+	for name, values := range ctx.defaultHeaders {
+		headers[name] = append([]string(nil), values...)
+	}
+
+	return headers
+}
+
+// applySpecialHeaders moves the Host and Content-Length headers, which the HTTP client ignores, to the request fields.
+// A removed Content-Length sends the body with chunked transfer encoding.
+func (ctx *ApiContext) applySpecialHeaders(req *http.Request) error {
+	if host := req.Header.Get("Host"); host != "" {
+		req.Host = host
+	}
+	req.Header.Del("Host")
+
+	if length := req.Header.Get("Content-Length"); length != "" {
+		contentLength, err := strconv.ParseInt(length, 10, 64)
+
+		if err != nil || contentLength < 0 {
+			return fmt.Errorf("invalid Content-Length header %s", length)
+		}
+
+		req.ContentLength = contentLength
+	}
+	req.Header.Del("Content-Length")
+
+	if ctx.removedHeaders["Content-Length"] && req.Body != nil && req.Body != http.NoBody {
+		req.ContentLength = -1
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package apicontext
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+type receivedRequest struct {
+	header           http.Header
+	host             string
+	contentLength    int64
+	transferEncoding []string
+	body             string
+}
+
+func setupHeadersTestServer(received *receivedRequest) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		*received = receivedRequest{
+			header:           r.Header,
+			host:             r.Host,
+			contentLength:    r.ContentLength,
+			transferEncoding: r.TransferEncoding,
+			body:             string(body),
+		}
+	}))
+}
+
+func TestApiContext_IAddHeaderWithValue(t *testing.T) {
+	var received receivedRequest
+	ts := setupHeadersTestServer(&received)
+	defer ts.Close()
+
+	ctx := New(ts.URL)
+	assert.Nil(t, ctx.StoreScopeData("lang", "pt"))
+	assert.Nil(t, ctx.IAddHeaderWithValue("Accept-Language", "en"))
+	assert.Nil(t, ctx.IAddHeaderWithValue("accept-language", "`##lang`"))
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+	assert.Equal(t, []string{"en", "pt"}, received.header["Accept-Language"])
+
+	assert.Nil(t, ctx.ISetHeaderWithValue("Accept-Language", "fr"))
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+	assert.Equal(t, []string{"fr"}, received.header["Accept-Language"])
+}
+
+func TestApiContext_WithDefaultHeaders(t *testing.T) {
+	var received receivedRequest
+	ts := setupHeadersTestServer(&received)
+	defer ts.Close()
+
+	ctx := New(ts.URL).
+		WithService("admin", ts.URL).
+		WithServiceHeader("admin", "Authorization", "Bearer admin").
+		WithDefaultHeaders(map[string]string{"X-Client": "tests", "X-Trace": "1"})
+
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+	assert.Equal(t, "tests", received.header.Get("X-Client"))
+	assert.NotEmpty(t, received.header.Get("User-Agent"))
+
+	assert.Nil(t, ctx.IRemoveHeader("X-Trace"))
+	assert.Nil(t, ctx.IRemoveHeader("User-Agent"))
+	assert.Nil(t, ctx.IRemoveHeader("Authorization"))
+	assert.Nil(t, ctx.ISendRequestTo("GET", "admin:/"))
+	assert.Equal(t, "tests", received.header.Get("X-Client"))
+	assert.NotContains(t, received.header, "X-Trace")
+	assert.NotContains(t, received.header, "User-Agent")
+	assert.NotContains(t, received.header, "Authorization")
+
+	assert.Nil(t, ctx.IAddHeaderWithValue("X-Trace", "2"))
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+	assert.Equal(t, "2", received.header.Get("X-Trace"))
+
+	ctx.reset(nil)
+	assert.Nil(t, ctx.ISendRequestTo("GET", "admin:/"))
+	assert.Equal(t, "1", received.header.Get("X-Trace"))
+	assert.Equal(t, "Bearer admin", received.header.Get("Authorization"))
+	assert.NotEmpty(t, received.header.Get("User-Agent"))
+}
+
+func TestApiContext_SpecialHeaders(t *testing.T) {
+	var received receivedRequest
+	ts := setupHeadersTestServer(&received)
+	defer ts.Close()
+
+	ctx := New(ts.URL)
+	assert.Nil(t, ctx.ISetHeaderWithValue("Host", "api.example.com"))
+	assert.Nil(t, ctx.ISendRequestToWithBody("POST", "/", &godog.DocString{Content: "hello"}))
+	assert.Equal(t, "api.example.com", received.host)
+	assert.Equal(t, int64(5), received.contentLength)
+
+	assert.Nil(t, ctx.IRemoveHeader("Content-Length"))
+	assert.Nil(t, ctx.ISendRequestToWithBody("POST", "/", &godog.DocString{Content: "hello"}))
+	assert.Equal(t, []string{"chunked"}, received.transferEncoding)
+	assert.Equal(t, "hello", received.body)
+
+	assert.Nil(t, ctx.ISetHeaderWithValue("Content-Length", "5"))
+	assert.Nil(t, ctx.ISendRequestToWithBody("POST", "/", &godog.DocString{Content: "hello"}))
+	assert.Equal(t, int64(5), received.contentLength)
+	assert.Empty(t, received.transferEncoding)
+
+	assert.Nil(t, ctx.ISetHeaderWithValue("Content-Length", "3"))
+	assert.Error(t, ctx.ISendRequestToWithBody("POST", "/", &godog.DocString{Content: "hello"}))
+
+	assert.Nil(t, ctx.ISetHeaderWithValue("Content-Length", "abc"))
+	assert.EqualError(t, ctx.ISendRequestTo("GET", "/"), "invalid Content-Length header abc")
+}
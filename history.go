@@ -0,0 +1,183 @@
+package apicontext
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cucumber/godog"
+)
+
+// exchange is a request sent in the scenario and its response, which is nil when the request failed.
+type exchange struct {
+	name        string
+	request     *http.Request
+	requestBody []byte
+	response    *ApiResponse
+	startedAt   time.Time
+	duration    time.Duration
+}
+
+// recordExchange adds the request and its response to the scenario exchanges and to the HAR entries.
+func (ctx *ApiContext) recordExchange(req *http.Request, body []byte, resp *ApiResponse, startedAt time.Time) {
+	e := &exchange{
+		request:     req,
+		requestBody: body,
+		response:    resp,
+		startedAt:   startedAt,
+		duration:    time.Since(startedAt),
+	}
+	ctx.exchanges = append(ctx.exchanges, e)
+
+	if ctx.harPath != "" {
+		ctx.harEntries = append(ctx.harEntries, newHAREntry(e, ctx.scenarioName, ctx.redactHeaders))
+	}
+}
+
+// requestBody returns a copy of the body of the request, without consuming it.
+func requestBody(req *http.Request) []byte {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+
+	if req.GetBody != nil {
+		r, err := req.GetBody()
+
+		if err != nil {
+			return nil
+		}
+
+		body, _ := ioutil.ReadAll(r)
+		return body
+	}
+
+	body, _ := ioutil.ReadAll(req.Body)
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	return body
+}
+
+// ISendRequestToAs Sends a request and names it, so its response can be checked after other requests are sent.
+func (ctx *ApiContext) ISendRequestToAs(method, uri, name string) error {
+	return ctx.sendNamedRequest(name, func() error {
+		return ctx.ISendRequestTo(method, uri)
+	})
+}
+
+// ISendRequestToAsWithBody Sends a request with the DocString as body and names it.
+func (ctx *ApiContext) ISendRequestToAsWithBody(method, uri, name string, requestBody *godog.DocString) error {
+	return ctx.sendNamedRequest(name, func() error {
+		return ctx.ISendRequestToWithBody(method, uri, requestBody)
+	})
+}
+
+// TheResponseCodeOfShouldBe Checks the status code of a previous response, referenced by name or by its position in the scenario, starting at 1.
+func (ctx *ApiContext) TheResponseCodeOfShouldBe(ref string, statusCode int) error {
+	return ctx.onResponse(ref, func() error {
+		return ctx.TheResponseCodeShouldBe(statusCode)
+	})
+}
+
+// TheResponseOfShouldMatchJSON Checks that the body of a previous response matches the json from the DocString.
+func (ctx *ApiContext) TheResponseOfShouldMatchJSON(ref string, body *godog.DocString) error {
+	return ctx.onResponse(ref, func() error {
+		return ctx.TheResponseShouldMatchJSON(body)
+	})
+}
+
+// TheResponseHeaderOfShouldHaveValue Checks the value of a header of a previous response.
+func (ctx *ApiContext) TheResponseHeaderOfShouldHaveValue(ref string, name string, expectedValue string) error {
+	return ctx.onResponse(ref, func() error {
+		return ctx.TheResponseHeaderShouldHaveValue(name, expectedValue)
+	})
+}
+
+// TheJSONPathOfResponseShouldHaveValue Validates the value at the specified json path of a previous response.
+func (ctx *ApiContext) TheJSONPathOfResponseShouldHaveValue(pathExpr string, ref string, expectedValue string) error {
+	return ctx.onResponse(ref, func() error {
+		return ctx.TheJSONPathShouldHaveValue(pathExpr, expectedValue)
+	})
+}
+
+// TheJSONPathOfResponseShouldMatch Validates the value at the specified json path of a previous response against a regular expression.
+func (ctx *ApiContext) TheJSONPathOfResponseShouldMatch(pathExpr string, ref string, pattern string) error {
+	return ctx.onResponse(ref, func() error {
+		return ctx.TheJSONPathShouldMatch(pathExpr, pattern)
+	})
+}
+
+// TheJSONPathOfResponseShouldBePresent Checks that the json path is present in a previous response.
+func (ctx *ApiContext) TheJSONPathOfResponseShouldBePresent(pathExpr string, ref string) error {
+	return ctx.onResponse(ref, func() error {
+		return ctx.TheJSONPathShouldBePresent(pathExpr)
+	})
+}
+
+// StoreJSONPathValueOfResponse Stores the value at the specified json path of a previous response in the scenario scope.
+func (ctx *ApiContext) StoreJSONPathValueOfResponse(pathExpr string, ref string, scopeKeyName string) error {
+	return ctx.onResponse(ref, func() error {
+		return ctx.StoreJsonPathValue(pathExpr, scopeKeyName)
+	})
+}
+
+// onResponse runs the assertion against a previous response, as if it was the last response.
+func (ctx *ApiContext) onResponse(ref string, assertion func() error) error {
+	e, err := ctx.exchangeByReference(ref)
+
+	if err != nil {
+		return err
+	}
+
+	if e.response == nil {
+		return fmt.Errorf("the request %s %s of response %s failed, so it has no response", e.request.Method, e.request.URL, ref)
+	}
+
+	last := ctx.lastResponse
+	ctx.lastResponse = e.response
+	defer func() {
+		ctx.lastResponse = last
+	}()
+
+	if err := assertion(); err != nil {
+		return fmt.Errorf("response %s: %v", ref, err)
+	}
+
+	return nil
+}
+
+// exchangeByReference returns the last exchange with the specified name or, if the reference is a number, the exchange at that position, starting at 1.
+func (ctx *ApiContext) exchangeByReference(ref string) (*exchange, error) {
+	for i := len(ctx.exchanges) - 1; i >= 0; i-- {
+		if ctx.exchanges[i].name == ref {
+			return ctx.exchanges[i], nil
+		}
+	}
+
+	if index, err := strconv.Atoi(ref); err == nil {
+		if index < 1 || index > len(ctx.exchanges) {
+			return nil, fmt.Errorf("there is no response %d, %d requests were sent in the scenario", index, len(ctx.exchanges))
+		}
+
+		return ctx.exchanges[index-1], nil
+	}
+
+	return nil, fmt.Errorf("there is no response named %s. Name it with: I send \"GET\" request to \"/path\" as \"%s\"", ref, ref)
+}
+
+// sendNamedRequest sends a request and names its exchange. A request that fails before it's sent, like one to an invalid URI,
+// records no exchange, so the name is not given to the previous one.
+func (ctx *ApiContext) sendNamedRequest(name string, send func() error) error {
+	recorded := len(ctx.exchanges)
+	err := send()
+
+	if len(ctx.exchanges) == recorded {
+		return err
+	}
+
+	ctx.exchanges[len(ctx.exchanges)-1].name = name
+
+	return err
+}
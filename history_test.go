@@ -0,0 +1,85 @@
+package apicontext
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupHistoryTestServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.Method {
+		case http.MethodPost:
+			w.Header().Set("Location", "/orders/42")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id": 42, "status": "created"}`))
+		case http.MethodGet:
+			_, _ = w.Write([]byte(`{"id": 42, "status": "paid"}`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+}
+
+func TestApiContext_ISendRequestToAs(t *testing.T) {
+	ts := setupHistoryTestServer()
+	defer ts.Close()
+
+	ctx := New(ts.URL)
+
+	assert.Nil(t, ctx.ISendRequestToAsWithBody("POST", "/orders", "create", &godog.DocString{Content: `{}`}))
+	assert.Nil(t, ctx.ISendRequestToAs("GET", "/orders/42", "fetch"))
+	assert.Nil(t, ctx.ISendRequestTo("DELETE", "/orders/42"))
+
+	assert.Nil(t, ctx.TheResponseCodeShouldBe(204))
+	assert.Nil(t, ctx.TheResponseCodeOfShouldBe("create", 201))
+	assert.Nil(t, ctx.TheResponseCodeOfShouldBe("1", 201))
+	assert.Nil(t, ctx.TheResponseCodeOfShouldBe("3", 204))
+	assert.Nil(t, ctx.TheResponseHeaderOfShouldHaveValue("create", "Location", "/orders/42"))
+	assert.Nil(t, ctx.TheJSONPathOfResponseShouldHaveValue("$.status", "create", "created"))
+	assert.Nil(t, ctx.TheJSONPathOfResponseShouldHaveValue("$.status", "fetch", "paid"))
+	assert.Nil(t, ctx.TheJSONPathOfResponseShouldMatch("$.status", "fetch", "^pa"))
+	assert.Nil(t, ctx.TheJSONPathOfResponseShouldBePresent("$.id", "create"))
+	assert.Nil(t, ctx.TheResponseOfShouldMatchJSON("fetch", &godog.DocString{Content: `{"status": "paid", "id": 42}`}))
+
+	assert.Nil(t, ctx.StoreJSONPathValueOfResponse("$.id", "create", "orderId"))
+	assert.Equal(t, "42", ctx.scope["orderId"])
+
+	assert.EqualError(t, ctx.TheResponseCodeOfShouldBe("create", 200), "response create: expected status code to be 200, but actual is 201.\n Response body: {\"id\": 42, \"status\": \"created\"}")
+	assert.EqualError(t, ctx.TheResponseCodeOfShouldBe("4", 200), "there is no response 4, 3 requests were sent in the scenario")
+	assert.Error(t, ctx.TheResponseCodeOfShouldBe("missing", 200))
+
+	// the last response is restored after checking a previous response
+	assert.Nil(t, ctx.TheResponseCodeShouldBe(204))
+
+	ctx.reset(nil)
+	assert.Error(t, ctx.TheResponseCodeOfShouldBe("create", 201))
+}
+
+func TestApiContext_ISendRequestToAsWithFailedRequest(t *testing.T) {
+	ctx := New("http://127.0.0.1:0")
+
+	assert.Error(t, ctx.ISendRequestToAs("GET", "/orders", "list"))
+	assert.EqualError(t, ctx.TheResponseCodeOfShouldBe("list", 200), "the request GET http://127.0.0.1:0/orders of response list failed, so it has no response")
+}
+
+func TestApiContext_ISendRequestToAsWithInvalidURI(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	ctx := New(ts.URL)
+
+	assert.Nil(t, ctx.ISendRequestToAs("GET", "/orders", "list"))
+	assert.Error(t, ctx.ISendRequestToAs("GET", "/orders/{id}", "order"))
+	assert.Error(t, ctx.ISendRequestToAsWithBody("POST", "/orders/{id}", "create", &godog.DocString{Content: `{}`}))
+
+	assert.Len(t, ctx.exchanges, 1)
+	assert.Nil(t, ctx.TheResponseCodeOfShouldBe("list", 200))
+	assert.EqualError(t, ctx.TheResponseCodeOfShouldBe("order", 200),
+		`there is no response named order. Name it with: I send "GET" request to "/path" as "order"`)
+}
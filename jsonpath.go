@@ -0,0 +1,579 @@
+package apicontext
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cucumber/godog"
+)
+
+// The names of the json types accepted by TheJSONPathShouldBeOfType.
+const (
+	jsonTypeObject  = "object"
+	jsonTypeArray   = "array"
+	jsonTypeString  = "string"
+	jsonTypeNumber  = "number"
+	jsonTypeBoolean = "boolean"
+	jsonTypeNull    = "null"
+)
+
+// TheJSONPathShouldNotBePresent checks that the specified json path does not exist in the response body
+func (ctx *ApiContext) TheJSONPathShouldNotBePresent(pathExpr string) error {
+	value, found, err := ctx.lookupJSONPath(pathExpr)
+
+	if err != nil {
+		return err
+	}
+
+	if found {
+		return jsonPathError(pathExpr, "to not be present", value)
+	}
+
+	return nil
+}
+
+// TheJSONPathShouldBeNull checks that the value at the specified json path is null
+func (ctx *ApiContext) TheJSONPathShouldBeNull(pathExpr string) error {
+	value, err := ctx.jsonPathValue(pathExpr)
+
+	if err != nil {
+		return err
+	}
+
+	if value != nil {
+		return jsonPathError(pathExpr, "to be null", value)
+	}
+
+	return nil
+}
+
+// TheJSONPathShouldBeOfType checks the json type (array, object, string, number, boolean or null) of the value at the specified json path
+func (ctx *ApiContext) TheJSONPathShouldBeOfType(pathExpr string, expectedType string) error {
+	value, err := ctx.jsonPathValue(pathExpr)
+
+	if err != nil {
+		return err
+	}
+
+	if actualType := jsonTypeOf(value); actualType != expectedType {
+		return jsonPathError(pathExpr, fmt.Sprintf("to be of type %s (found %s)", expectedType, actualType), value)
+	}
+
+	return nil
+}
+
+// TheJSONPathShouldBeEmpty checks that the value at the specified json path is an empty string, array or object, or null
+func (ctx *ApiContext) TheJSONPathShouldBeEmpty(pathExpr string) error {
+	value, err := ctx.jsonPathValue(pathExpr)
+
+	if err != nil {
+		return err
+	}
+
+	empty := false
+	switch v := value.(type) {
+	case nil:
+		empty = true
+	case string:
+		empty = v == ""
+	case []interface{}:
+		empty = len(v) == 0
+	case map[string]interface{}:
+		empty = len(v) == 0
+	}
+
+	if !empty {
+		return jsonPathError(pathExpr, "to be empty", value)
+	}
+
+	return nil
+}
+
+// TheJSONPathShouldContain checks if the value at the specified json path contains the expected value.
+// Strings are checked for a substring, arrays for an element with the expected value and objects for a key.
+func (ctx *ApiContext) TheJSONPathShouldContain(pathExpr string, expectedValue string) error {
+	value, err := ctx.jsonPathValue(pathExpr)
+
+	if err != nil {
+		return err
+	}
+
+	expectedValue = ctx.ReplaceScopeVariables(expectedValue)
+
+	contains := false
+	switch v := value.(type) {
+	case string:
+		contains = strings.Contains(v, expectedValue)
+	case []interface{}:
+		for _, item := range v {
+			if match, _ := jsonValueEquals(item, expectedValue); match {
+				contains = true
+				break
+			}
+		}
+	case map[string]interface{}:
+		_, contains = v[expectedValue]
+	default:
+		return jsonPathError(pathExpr, "to be a string, array or object", value)
+	}
+
+	if !contains {
+		return jsonPathError(pathExpr, fmt.Sprintf("to contain %s", expectedValue), value)
+	}
+
+	return nil
+}
+
+// TheJSONPathShouldBeGreaterThan checks if the number at the specified json path is greater than the expected value
+func (ctx *ApiContext) TheJSONPathShouldBeGreaterThan(pathExpr string, expected float64) error {
+	actual, err := ctx.jsonPathNumber(pathExpr)
+
+	if err != nil {
+		return err
+	}
+
+	if actual <= expected {
+		return jsonPathError(pathExpr, fmt.Sprintf("to be greater than %v", expected), actual)
+	}
+
+	return nil
+}
+
+// TheJSONPathShouldBeLessThan checks if the number at the specified json path is less than the expected value
+func (ctx *ApiContext) TheJSONPathShouldBeLessThan(pathExpr string, expected float64) error {
+	actual, err := ctx.jsonPathNumber(pathExpr)
+
+	if err != nil {
+		return err
+	}
+
+	if actual >= expected {
+		return jsonPathError(pathExpr, fmt.Sprintf("to be less than %v", expected), actual)
+	}
+
+	return nil
+}
+
+// TheJSONPathShouldBeBetween checks if the number at the specified json path is between min and max (inclusive)
+func (ctx *ApiContext) TheJSONPathShouldBeBetween(pathExpr string, min float64, max float64) error {
+	actual, err := ctx.jsonPathNumber(pathExpr)
+
+	if err != nil {
+		return err
+	}
+
+	if actual < min || actual > max {
+		return jsonPathError(pathExpr, fmt.Sprintf("to be between %v and %v", min, max), actual)
+	}
+
+	return nil
+}
+
+// TheJSONPathShouldBeOneOf checks if the value at the specified json path is one of the comma separated values
+func (ctx *ApiContext) TheJSONPathShouldBeOneOf(pathExpr string, values string) error {
+	value, err := ctx.jsonPathValue(pathExpr)
+
+	if err != nil {
+		return err
+	}
+
+	values = ctx.ReplaceScopeVariables(values)
+	for _, expectedValue := range strings.Split(values, ",") {
+		if match, _ := jsonValueEquals(value, strings.TrimSpace(expectedValue)); match {
+			return nil
+		}
+	}
+
+	return jsonPathError(pathExpr, fmt.Sprintf("to be one of %s", values), value)
+}
+
+// TheJSONPathShouldHaveJSONValue compares the value at the specified json path with the json document from the DocString
+func (ctx *ApiContext) TheJSONPathShouldHaveJSONValue(pathExpr string, body *godog.DocString) error {
+	value, err := ctx.jsonPathValue(pathExpr)
+
+	if err != nil {
+		return err
+	}
+
+	expected := ctx.ReplaceScopeVariables(body.Content)
+	match, err := isEqualJson(jsonValueToJSON(value), expected)
+
+	if err != nil {
+		return err
+	}
+
+	if !match {
+		return jsonPathError(pathExpr, fmt.Sprintf("to have value %s", expected), value)
+	}
+
+	return nil
+}
+
+// TheJSONPathShouldHaveLength checks the number of characters of the string at the specified json path
+func (ctx *ApiContext) TheJSONPathShouldHaveLength(pathExpr string, expectedLength int) error {
+	value, err := ctx.jsonPathValue(pathExpr)
+
+	if err != nil {
+		return err
+	}
+
+	s, ok := value.(string)
+
+	if !ok {
+		return jsonPathError(pathExpr, "to be a string", value)
+	}
+
+	if utf8.RuneCountInString(s) != expectedLength {
+		return jsonPathError(pathExpr, fmt.Sprintf("to have length %d", expectedLength), value)
+	}
+
+	return nil
+}
+
+// jsonPathValue returns the value at the specified json path of the last response body.
+// It returns an error if the path is not present in the response.
+func (ctx *ApiContext) jsonPathValue(pathExpr string) (interface{}, error) {
+	value, found, err := ctx.lookupJSONPath(pathExpr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, fmt.Errorf("the json path %s was not present in the response", pathExpr)
+	}
+
+	return value, nil
+}
+
+// lookupJSONPath evaluates the json path against the decoded last response body, using the dialect configured in the context.
+// A path that cannot be resolved in the document is reported as not found, while an invalid expression or body is an error.
+func (ctx *ApiContext) lookupJSONPath(pathExpr string) (interface{}, bool, error) {
+	if ctx.lastResponse == nil {
+		return nil, false, errors.New("no response available. Send a request first")
+	}
+
+	data, err := ctx.lastResponse.Decoded()
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return ctx.evaluatePath(pathExpr, data)
+}
+
+// jsonPathNumber returns the number at the specified json path of the last response body.
+func (ctx *ApiContext) jsonPathNumber(pathExpr string) (float64, error) {
+	value, err := ctx.jsonPathValue(pathExpr)
+
+	if err != nil {
+		return 0, err
+	}
+
+	n, ok := value.(float64)
+
+	if !ok {
+		return 0, jsonPathError(pathExpr, "to be a number", value)
+	}
+
+	return n, nil
+}
+
+// jsonPathError builds the error returned by the json path steps when an expectation is not met.
+func jsonPathError(pathExpr string, expectation string, actual interface{}) error {
+	return fmt.Errorf("expected json path %s %s, but it is %s", pathExpr, expectation, jsonValueToJSON(actual))
+}
+
+// jsonValueEquals compares a decoded json value with the string representation used in the step definitions.
+func jsonValueEquals(actual interface{}, expected string) (bool, error) {
+	switch v := actual.(type) {
+	case nil:
+		return expected == jsonTypeNull, nil
+	case bool:
+		b, err := strconv.ParseBool(expected)
+		if err != nil {
+			return false, err
+		}
+		return v == b, nil
+	case float64:
+		f, err := strconv.ParseFloat(expected, 64)
+		if err != nil {
+			return false, err
+		}
+		return v == f, nil
+	case string:
+		return v == expected, nil
+	default:
+		return isEqualJson(jsonValueToJSON(actual), expected)
+	}
+}
+
+// jsonTypeOf returns the json type name of a decoded json value.
+func jsonTypeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return jsonTypeNull
+	case map[string]interface{}:
+		return jsonTypeObject
+	case []interface{}:
+		return jsonTypeArray
+	case string:
+		return jsonTypeString
+	case float64:
+		return jsonTypeNumber
+	case bool:
+		return jsonTypeBoolean
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// jsonValueToString returns strings as is and any other value encoded as json.
+func jsonValueToString(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+
+	return jsonValueToJSON(value)
+}
+
+// jsonValueToJSON encodes a decoded json value back to json.
+func jsonValueToJSON(value interface{}) string {
+	b, err := json.Marshal(value)
+
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+
+	return string(b)
+}
+
+// EveryElementOfJSONPathShouldHave checks that the field of every element of the array at the specified json path
+// is "equal to" or "matching" the expected value.
+func (ctx *ApiContext) EveryElementOfJSONPathShouldHave(pathExpr string, field string, operator string, expected string) error {
+	elements, err := ctx.jsonPathElements(pathExpr)
+
+	if err != nil {
+		return err
+	}
+
+	expected = ctx.ReplaceScopeVariables(expected)
+	for i, element := range elements {
+		match, err := ctx.elementFieldMatches(element, field, operator, expected)
+
+		if err != nil {
+			return err
+		}
+
+		if !match {
+			return jsonPathError(pathExpr, fmt.Sprintf("to only have elements with %s %s %s (element %d doesn't)", field, operator, expected, i), elements)
+		}
+	}
+
+	return nil
+}
+
+// AtLeastOneElementOfJSONPathShouldHave checks that the field of at least one element of the array at the specified
+// json path is "equal to" or "matching" the expected value.
+func (ctx *ApiContext) AtLeastOneElementOfJSONPathShouldHave(pathExpr string, field string, operator string, expected string) error {
+	count, elements, err := ctx.countMatchingElements(pathExpr, field, operator, expected)
+
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return jsonPathError(pathExpr, fmt.Sprintf("to have at least one element with %s %s %s", field, operator, ctx.ReplaceScopeVariables(expected)), elements)
+	}
+
+	return nil
+}
+
+// NoElementOfJSONPathShouldHave checks that the field of none of the elements of the array at the specified json path
+// is "equal to" or "matching" the expected value.
+func (ctx *ApiContext) NoElementOfJSONPathShouldHave(pathExpr string, field string, operator string, expected string) error {
+	count, elements, err := ctx.countMatchingElements(pathExpr, field, operator, expected)
+
+	if err != nil {
+		return err
+	}
+
+	if count != 0 {
+		return jsonPathError(pathExpr, fmt.Sprintf("to have no element with %s %s %s (found %d)", field, operator, ctx.ReplaceScopeVariables(expected), count), elements)
+	}
+
+	return nil
+}
+
+// TheJSONPathShouldBeSorted checks that the array at the specified json path is sorted in ascending or descending order.
+// Numbers are compared numerically and strings lexicographically, which also works for ISO 8601 dates.
+func (ctx *ApiContext) TheJSONPathShouldBeSorted(pathExpr string, order string) error {
+	elements, err := ctx.jsonPathElements(pathExpr)
+
+	if err != nil {
+		return err
+	}
+
+	for i := 1; i < len(elements); i++ {
+		cmp, err := compareJSONValues(elements[i-1], elements[i])
+
+		if err != nil {
+			return jsonPathError(pathExpr, fmt.Sprintf("to be sortable (%v)", err), elements)
+		}
+
+		if (order == "ascending" && cmp > 0) || (order == "descending" && cmp < 0) {
+			return jsonPathError(pathExpr, fmt.Sprintf("to be sorted %s (element %d is out of order)", order, i), elements)
+		}
+	}
+
+	return nil
+}
+
+// jsonPathElements returns the array at the specified json path, like the results of a wildcard expression.
+func (ctx *ApiContext) jsonPathElements(pathExpr string) ([]interface{}, error) {
+	value, err := ctx.jsonPathValue(pathExpr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	elements, ok := value.([]interface{})
+
+	if !ok {
+		return nil, jsonPathError(pathExpr, "to be an array", value)
+	}
+
+	return elements, nil
+}
+
+// countMatchingElements returns how many elements of the array at the specified json path have a field matching the expected value.
+func (ctx *ApiContext) countMatchingElements(pathExpr string, field string, operator string, expected string) (int, []interface{}, error) {
+	elements, err := ctx.jsonPathElements(pathExpr)
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	expected = ctx.ReplaceScopeVariables(expected)
+	count := 0
+	for _, element := range elements {
+		match, err := ctx.elementFieldMatches(element, field, operator, expected)
+
+		if err != nil {
+			return 0, nil, err
+		}
+
+		if match {
+			count++
+		}
+	}
+
+	return count, elements, nil
+}
+
+// elementFieldMatches checks the field of a single array element against the expected value.
+// The field is a path relative to the element, like "status" or "$.status" with the json path dialect.
+func (ctx *ApiContext) elementFieldMatches(element interface{}, field string, operator string, expected string) (bool, error) {
+	value, found, err := ctx.evaluatePath(ctx.relativePath(field), element)
+
+	if err != nil || !found {
+		// elements without the field never match.
+		return false, err
+	}
+
+	switch operator {
+	case "matching":
+		return regexp.MatchString(expected, jsonValueToString(value))
+	default:
+		match, _ := jsonValueEquals(value, expected)
+		return match, nil
+	}
+}
+
+// compareJSONValues compares two numbers or two strings, returning -1, 0 or 1.
+func compareJSONValues(a interface{}, b interface{}) (int, error) {
+	switch av := a.(type) {
+	case float64:
+		if bv, ok := b.(float64); ok {
+			switch {
+			case av < bv:
+				return -1, nil
+			case av > bv:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	case string:
+		if bv, ok := b.(string); ok {
+			return strings.Compare(av, bv), nil
+		}
+	}
+
+	return 0, fmt.Errorf("cannot compare %s with %s", jsonTypeOf(a), jsonTypeOf(b))
+}
+
+// TheResponseShouldHaveTheFollowingJSONPaths evaluates several json path assertions from a Data Table with the columns
+// path, operator and value. The supported operators are equals, matches, contains, type, count, present and absent.
+// All the rows are evaluated and every failure is reported at once.
+func (ctx *ApiContext) TheResponseShouldHaveTheFollowingJSONPaths(dt *godog.Table) error {
+	var failures []string
+
+	for i := 0; i < len(dt.Rows); i++ {
+		cells := dt.Rows[i].Cells
+
+		if i == 0 && len(cells) > 1 && strings.EqualFold(cells[0].Value, "path") && strings.EqualFold(cells[1].Value, "operator") {
+			continue
+		}
+
+		if len(cells) < 2 {
+			failures = append(failures, fmt.Sprintf("row %d: expected at least the path and operator columns", i+1))
+			continue
+		}
+
+		value := ""
+		if len(cells) > 2 {
+			value = cells[2].Value
+		}
+
+		if err := ctx.assertJSONPath(cells[0].Value, cells[1].Value, value); err != nil {
+			failures = append(failures, fmt.Sprintf("row %d: %v", i+1, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%d json path assertion(s) failed:\n%s", len(failures), strings.Join(failures, "\n"))
+	}
+
+	return nil
+}
+
+// assertJSONPath runs the json path step matching the operator used in TheResponseShouldHaveTheFollowingJSONPaths.
+func (ctx *ApiContext) assertJSONPath(pathExpr string, operator string, value string) error {
+	switch strings.ToLower(strings.TrimSpace(operator)) {
+	case "equals":
+		return ctx.TheJSONPathShouldHaveValue(pathExpr, value)
+	case "matches":
+		return ctx.TheJSONPathShouldMatch(pathExpr, value)
+	case "contains":
+		return ctx.TheJSONPathShouldContain(pathExpr, value)
+	case "type":
+		return ctx.TheJSONPathShouldBeOfType(pathExpr, value)
+	case "count":
+		count, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid count %s for json path %s", value, pathExpr)
+		}
+		return ctx.TheJSONPathHaveCount(pathExpr, count)
+	case "present":
+		return ctx.TheJSONPathShouldBePresent(pathExpr)
+	case "absent":
+		return ctx.TheJSONPathShouldNotBePresent(pathExpr)
+	default:
+		return fmt.Errorf("unknown json path operator %s", operator)
+	}
+}
@@ -0,0 +1,190 @@
+package apicontext
+
+import (
+	"testing"
+
+	"github.com/cucumber/godog"
+	"github.com/cucumber/messages-go/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiContext_TheJSONPathShouldNotBePresent(t *testing.T) {
+	ctx, teardown := setupResponseTestContext(t, "application/json", readTestData(t, "test_json_path.json"))
+	defer teardown()
+
+	assert.Nil(t, ctx.TheJSONPathShouldNotBePresent("$.missing"))
+	assert.Nil(t, ctx.TheJSONPathShouldNotBePresent("$.list[5]"))
+	assert.Error(t, ctx.TheJSONPathShouldNotBePresent("$.a"))
+	assert.Error(t, ctx.TheJSONPathShouldNotBePresent("$.e"))
+	assert.Error(t, ctx.TheJSONPathShouldNotBePresent("$[?"))
+}
+
+func TestApiContext_TheJSONPathShouldBeNull(t *testing.T) {
+	ctx, teardown := setupResponseTestContext(t, "application/json", readTestData(t, "test_json_path.json"))
+	defer teardown()
+
+	assert.Nil(t, ctx.TheJSONPathShouldBeNull("$.e"))
+	assert.Error(t, ctx.TheJSONPathShouldBeNull("$.a"))
+	assert.Error(t, ctx.TheJSONPathShouldBeNull("$.missing"))
+}
+
+func TestApiContext_TheJSONPathShouldBeOfType(t *testing.T) {
+	ctx, teardown := setupResponseTestContext(t, "application/json", readTestData(t, "test_json_path.json"))
+	defer teardown()
+
+	assert.Nil(t, ctx.TheJSONPathShouldBeOfType("$.a", "string"))
+	assert.Nil(t, ctx.TheJSONPathShouldBeOfType("$.b", "number"))
+	assert.Nil(t, ctx.TheJSONPathShouldBeOfType("$.d", "boolean"))
+	assert.Nil(t, ctx.TheJSONPathShouldBeOfType("$.e", "null"))
+	assert.Nil(t, ctx.TheJSONPathShouldBeOfType("$.list", "array"))
+	assert.Nil(t, ctx.TheJSONPathShouldBeOfType("$.object", "object"))
+
+	err := ctx.TheJSONPathShouldBeOfType("$.a", "number")
+	assert.EqualError(t, err, `expected json path $.a to be of type number (found string), but it is "a"`)
+}
+
+func TestApiContext_TheJSONPathShouldBeEmpty(t *testing.T) {
+	ctx, teardown := setupResponseTestContext(t, "application/json", readTestData(t, "test_json_path.json"))
+	defer teardown()
+
+	assert.Nil(t, ctx.TheJSONPathShouldBeEmpty("$.empty"))
+	assert.Nil(t, ctx.TheJSONPathShouldBeEmpty("$.emptyList"))
+	assert.Nil(t, ctx.TheJSONPathShouldBeEmpty("$.e"))
+	assert.Error(t, ctx.TheJSONPathShouldBeEmpty("$.list"))
+	assert.Error(t, ctx.TheJSONPathShouldBeEmpty("$.b"))
+}
+
+func TestApiContext_TheJSONPathShouldContain(t *testing.T) {
+	ctx, teardown := setupResponseTestContext(t, "application/json", readTestData(t, "test_json_path.json"))
+	defer teardown()
+
+	assert.Nil(t, ctx.TheJSONPathShouldContain("$.list", "item2"))
+	assert.Nil(t, ctx.TheJSONPathShouldContain("$.list[0]", "item"))
+	assert.Nil(t, ctx.TheJSONPathShouldContain("$.object", "key"))
+	assert.Error(t, ctx.TheJSONPathShouldContain("$.list", "item3"))
+	assert.Error(t, ctx.TheJSONPathShouldContain("$.b", "2"))
+}
+
+func TestApiContext_TheJSONPathNumericComparisons(t *testing.T) {
+	ctx, teardown := setupResponseTestContext(t, "application/json", readTestData(t, "test_json_path.json"))
+	defer teardown()
+
+	assert.Nil(t, ctx.TheJSONPathShouldBeGreaterThan("$.c", 3))
+	assert.Error(t, ctx.TheJSONPathShouldBeGreaterThan("$.c", 3.5))
+	assert.Nil(t, ctx.TheJSONPathShouldBeLessThan("$.b", 2.5))
+	assert.Error(t, ctx.TheJSONPathShouldBeLessThan("$.b", 2))
+	assert.Nil(t, ctx.TheJSONPathShouldBeBetween("$.object.number", 10, 20))
+	assert.Error(t, ctx.TheJSONPathShouldBeBetween("$.object.number", 11, 20))
+	assert.Error(t, ctx.TheJSONPathShouldBeGreaterThan("$.a", 1))
+}
+
+func TestApiContext_TheJSONPathShouldBeOneOf(t *testing.T) {
+	ctx, teardown := setupResponseTestContext(t, "application/json", readTestData(t, "test_json_path.json"))
+	defer teardown()
+
+	assert.Nil(t, ctx.TheJSONPathShouldBeOneOf("$.a", "x, a, z"))
+	assert.Nil(t, ctx.TheJSONPathShouldBeOneOf("$.b", "1,2,3"))
+	assert.Error(t, ctx.TheJSONPathShouldBeOneOf("$.a", "x, y"))
+}
+
+func TestApiContext_TheJSONPathShouldHaveJSONValue(t *testing.T) {
+	ctx, teardown := setupResponseTestContext(t, "application/json", readTestData(t, "test_json_path.json"))
+	defer teardown()
+
+	assert.Nil(t, ctx.TheJSONPathShouldHaveJSONValue("$.object", &godog.DocString{
+		Content: `{"number": 10, "key": "value"}`,
+	}))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveJSONValue("$.list", &godog.DocString{
+		Content: `["item1", "item2"]`,
+	}))
+	assert.Error(t, ctx.TheJSONPathShouldHaveJSONValue("$.object", &godog.DocString{
+		Content: `{"key": "other"}`,
+	}))
+}
+
+func TestApiContext_TheJSONPathShouldHaveLength(t *testing.T) {
+	ctx, teardown := setupResponseTestContext(t, "application/json", readTestData(t, "test_json_path.json"))
+	defer teardown()
+
+	assert.Nil(t, ctx.TheJSONPathShouldHaveLength("$.object.key", 5))
+	assert.Error(t, ctx.TheJSONPathShouldHaveLength("$.object.key", 4))
+	assert.Error(t, ctx.TheJSONPathShouldHaveLength("$.list", 2))
+}
+
+func TestApiContext_EveryElementOfJSONPathShouldHave(t *testing.T) {
+	ctx, teardown := setupResponseTestContext(t, "application/json", readTestData(t, "test_json_items.json"))
+	defer teardown()
+
+	assert.Nil(t, ctx.EveryElementOfJSONPathShouldHave("$.items[*]", "status", "equal to", "active"))
+	assert.Nil(t, ctx.EveryElementOfJSONPathShouldHave("$.items[*]", "$.createdAt", "matching", "^2021-"))
+	assert.Error(t, ctx.EveryElementOfJSONPathShouldHave("$.items[*]", "type", "equal to", "order"))
+	assert.Error(t, ctx.EveryElementOfJSONPathShouldHave("$.items[0]", "type", "equal to", "order"))
+}
+
+func TestApiContext_AtLeastOneElementOfJSONPathShouldHave(t *testing.T) {
+	ctx, teardown := setupResponseTestContext(t, "application/json", readTestData(t, "test_json_items.json"))
+	defer teardown()
+
+	assert.Nil(t, ctx.AtLeastOneElementOfJSONPathShouldHave("$.items[*]", "type", "equal to", "refund"))
+	assert.Nil(t, ctx.AtLeastOneElementOfJSONPathShouldHave("$.items", "id", "equal to", "2"))
+	assert.Error(t, ctx.AtLeastOneElementOfJSONPathShouldHave("$.items[*]", "type", "matching", "^cancel"))
+}
+
+func TestApiContext_NoElementOfJSONPathShouldHave(t *testing.T) {
+	ctx, teardown := setupResponseTestContext(t, "application/json", readTestData(t, "test_json_items.json"))
+	defer teardown()
+
+	assert.Nil(t, ctx.NoElementOfJSONPathShouldHave("$.items[*]", "status", "equal to", "deleted"))
+	assert.Nil(t, ctx.NoElementOfJSONPathShouldHave("$.items[*]", "missing", "equal to", "x"))
+	assert.Error(t, ctx.NoElementOfJSONPathShouldHave("$.items[*]", "type", "equal to", "refund"))
+}
+
+func TestApiContext_TheJSONPathShouldBeSorted(t *testing.T) {
+	ctx, teardown := setupResponseTestContext(t, "application/json", readTestData(t, "test_json_items.json"))
+	defer teardown()
+
+	assert.Nil(t, ctx.TheJSONPathShouldBeSorted("$.items[*].createdAt", "descending"))
+	assert.Nil(t, ctx.TheJSONPathShouldBeSorted("$.items[*].id", "descending"))
+	assert.Error(t, ctx.TheJSONPathShouldBeSorted("$.items[*].id", "ascending"))
+	assert.Error(t, ctx.TheJSONPathShouldBeSorted("$.items[*].type", "ascending"))
+}
+
+func TestApiContext_TheResponseShouldHaveTheFollowingJSONPaths(t *testing.T) {
+	ctx, teardown := setupResponseTestContext(t, "application/json", readTestData(t, "test_json_path.json"))
+	defer teardown()
+
+	table := func(rows ...[]string) *godog.Table {
+		dt := &godog.Table{}
+		for _, row := range rows {
+			r := &messages.PickleStepArgument_PickleTable_PickleTableRow{}
+			for _, value := range row {
+				r.Cells = append(r.Cells, &messages.PickleStepArgument_PickleTable_PickleTableRow_PickleTableCell{Value: value})
+			}
+			dt.Rows = append(dt.Rows, r)
+		}
+		return dt
+	}
+
+	assert.Nil(t, ctx.TheResponseShouldHaveTheFollowingJSONPaths(table(
+		[]string{"path", "operator", "value"},
+		[]string{"$.a", "equals", "a"},
+		[]string{"$.object.key", "matches", "^val"},
+		[]string{"$.list", "contains", "item1"},
+		[]string{"$.object", "type", "object"},
+		[]string{"$.list", "count", "2"},
+		[]string{"$.d", "present", ""},
+		[]string{"$.missing", "absent"},
+	)))
+
+	err := ctx.TheResponseShouldHaveTheFollowingJSONPaths(table(
+		[]string{"$.a", "equals", "b"},
+		[]string{"$.b", "equals", "2"},
+		[]string{"$.list", "count", "3"},
+		[]string{"$.a", "between", "1"},
+	))
+
+	assert.EqualError(t, err, "3 json path assertion(s) failed:\n"+
+		"row 1: expected json path $.a to have value b, but it is \"a\"\n"+
+		"row 3: expected json path $.list to have count 3, but it is [\"item1\",\"item2\"]\n"+
+		"row 4: unknown json path operator between")
+}
@@ -0,0 +1,250 @@
+package apicontext
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// LogLevel is the severity of a log entry.
+type LogLevel int
+
+const (
+	// LogLevelDebug is used for the requests and responses.
+	LogLevelDebug LogLevel = iota
+	// LogLevelInfo is the default minimum level of the logged entries.
+	LogLevelInfo
+	// LogLevelWarn is used for unexpected situations that don't fail a step.
+	LogLevelWarn
+	// LogLevelError is used for the requests and responses of failed steps, when logging only on failure.
+	LogLevelError
+)
+
+// The value that replaces redacted header values in logs.
+const redactedValue = "****"
+
+// The headers redacted from the logs by default.
+var defaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "Api-Key", "X-Auth-Token"}
+
+// String returns the name of the level.
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "DEBUG"
+	case LogLevelInfo:
+		return "INFO"
+	case LogLevelWarn:
+		return "WARN"
+	case LogLevelError:
+		return "ERROR"
+	default:
+		return fmt.Sprintf("LEVEL(%d)", int(l))
+	}
+}
+
+// LogField is a key value pair of a log entry, like the method of a request or its headers.
+type LogField struct {
+	Key   string
+	Value interface{}
+}
+
+// LogEntry is a message logged by the context, with its structured fields.
+type LogEntry struct {
+	Level   LogLevel
+	Message string
+	Fields  []LogField
+}
+
+// Field returns the value of the field with the specified key, or nil if the entry does not have it.
+func (e LogEntry) Field(key string) interface{} {
+	for _, field := range e.Fields {
+		if field.Key == key {
+			return field.Value
+		}
+	}
+
+	return nil
+}
+
+// Logger receives the entries logged by the context.
+type Logger interface {
+	Log(entry LogEntry)
+}
+
+// LoggerFunc is an adapter to use ordinary functions as loggers.
+type LoggerFunc func(entry LogEntry)
+
+// Log calls f(entry).
+func (f LoggerFunc) Log(entry LogEntry) {
+	f(entry)
+}
+
+// StdLogger writes the entries in a human readable format, using the standard log package.
+type StdLogger struct{}
+
+// Log writes the entry, with the headers one per line and the body at the end.
+func (StdLogger) Log(entry LogEntry) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] %s", entry.Level, entry.Message)
+
+	var headers http.Header
+	var body string
+
+	for _, field := range entry.Fields {
+		switch value := field.Value.(type) {
+		case http.Header:
+			headers = value
+		default:
+			if field.Key == "body" {
+				body = fmt.Sprint(value)
+				continue
+			}
+			fmt.Fprintf(&b, " %s=%v", field.Key, value)
+		}
+	}
+
+	for _, header := range sortedHeaders(headers) {
+		fmt.Fprintf(&b, "\n%s: %s", header.Name, header.Value)
+	}
+
+	if body != "" {
+		fmt.Fprintf(&b, "\n\n%s", body)
+	}
+
+	log.Println(b.String())
+}
+
+// WithLogger Configures the logger of the requests and responses. By default they are written with the standard log package.
+func (ctx *ApiContext) WithLogger(logger Logger) *ApiContext {
+	ctx.logger = logger
+	return ctx
+}
+
+// WithLogLevel Configures the minimum level of the logged entries. The requests and responses are logged with LogLevelDebug.
+func (ctx *ApiContext) WithLogLevel(level LogLevel) *ApiContext {
+	ctx.logLevel = level
+	return ctx
+}
+
+// WithLogBodyLimit Truncates the logged bodies to the specified number of bytes. Zero means no limit.
+func (ctx *ApiContext) WithLogBodyLimit(limit int) *ApiContext {
+	ctx.logBodyLimit = limit
+	return ctx
+}
+
+// WithRedactedHeaders Adds headers whose values are replaced by **** in the logs, besides Authorization, Cookie and the common API key headers.
+func (ctx *ApiContext) WithRedactedHeaders(names ...string) *ApiContext {
+	for _, name := range names {
+		ctx.redactedHeaders[http.CanonicalHeaderKey(name)] = true
+	}
+
+	return ctx
+}
+
+// WithLogOnFailure Buffers the requests and responses of each step, and logs them with LogLevelError only if the step fails.
+func (ctx *ApiContext) WithLogOnFailure(enabled bool) *ApiContext {
+	ctx.logOnFailure = enabled
+	return ctx
+}
+
+// logRequest logs the request, with its headers and body.
+func (ctx *ApiContext) logRequest(req *http.Request) {
+	ctx.log(LogEntry{
+		Level:   LogLevelDebug,
+		Message: "request",
+		Fields: []LogField{
+			{Key: "method", Value: req.Method},
+			{Key: "url", Value: req.URL.String()},
+			{Key: "headers", Value: ctx.redactHeaders(req.Header)},
+			{Key: "body", Value: ctx.formatLogBody(requestBody(req))},
+		},
+	})
+}
+
+// logResponse logs the response, with its headers and body.
+func (ctx *ApiContext) logResponse(resp *http.Response, body []byte, duration time.Duration) {
+	ctx.log(LogEntry{
+		Level:   LogLevelDebug,
+		Message: "response",
+		Fields: []LogField{
+			{Key: "status", Value: resp.StatusCode},
+			{Key: "duration", Value: duration},
+			{Key: "headers", Value: ctx.redactHeaders(resp.Header)},
+			{Key: "body", Value: ctx.formatLogBody(body)},
+		},
+	})
+}
+
+// log sends the entry to the logger, or buffers it until the end of the step when logging only on failure.
+func (ctx *ApiContext) log(entry LogEntry) {
+	if ctx.logOnFailure && entry.Level == LogLevelDebug {
+		ctx.logBuffer = append(ctx.logBuffer, entry)
+		return
+	}
+
+	if entry.Level < ctx.logLevel {
+		return
+	}
+
+	ctx.logger.Log(ctx.maskLogEntry(entry))
+}
+
+// flushLogs logs the buffered entries of the step, if it failed, and clears the buffer.
+func (ctx *ApiContext) flushLogs(failed bool) {
+	buffer := ctx.logBuffer
+	ctx.logBuffer = nil
+
+	if !failed {
+		return
+	}
+
+	for _, entry := range buffer {
+		entry.Level = LogLevelError
+		ctx.log(entry)
+	}
+}
+
+// redactHeaders returns a copy of the headers, with the values of the sensitive headers replaced.
+func (ctx *ApiContext) redactHeaders(header http.Header) http.Header {
+	redacted := header.Clone()
+
+	for name, values := range redacted {
+		if !ctx.redactedHeaders[http.CanonicalHeaderKey(name)] {
+			continue
+		}
+
+		for i := range values {
+			values[i] = redactedValue
+		}
+	}
+
+	return redacted
+}
+
+// formatLogBody pretty prints json bodies and truncates the body to the configured limit.
+func (ctx *ApiContext) formatLogBody(body []byte) string {
+	var pretty bytes.Buffer
+	if json.Valid(body) && json.Indent(&pretty, body, "", "  ") == nil {
+		body = pretty.Bytes()
+	}
+
+	if ctx.logBodyLimit > 0 && len(body) > ctx.logBodyLimit {
+		return fmt.Sprintf("%s... (%d bytes truncated)", body[:ctx.logBodyLimit], len(body)-ctx.logBodyLimit)
+	}
+
+	return string(body)
+}
+
+// newRedactedHeaders returns the set of headers redacted by default.
+func newRedactedHeaders() map[string]bool {
+	headers := map[string]bool{}
+	for _, name := range defaultRedactedHeaders {
+		headers[http.CanonicalHeaderKey(name)] = true
+	}
+
+	return headers
+}
@@ -0,0 +1,121 @@
+package apicontext
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupLoggingTestContext() (*ApiContext, *[]LogEntry, func()) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=abc")
+		_, _ = w.Write([]byte(`{"id":1,"name":"John"}`))
+	}))
+
+	var entries []LogEntry
+	ctx := New(ts.URL).WithLogger(LoggerFunc(func(entry LogEntry) {
+		entries = append(entries, entry)
+	}))
+
+	return ctx, &entries, ts.Close
+}
+
+func TestApiContext_WithDebug(t *testing.T) {
+	ctx := New("https://example.com")
+	assert.Equal(t, LogLevelInfo, ctx.logLevel)
+
+	ctx.WithDebug(true)
+	assert.Equal(t, LogLevelDebug, ctx.logLevel)
+
+	ctx.WithDebug(false)
+	assert.Equal(t, LogLevelInfo, ctx.logLevel)
+}
+
+func TestApiContext_WithLogger(t *testing.T) {
+	ctx, entries, closeServer := setupLoggingTestContext()
+	defer closeServer()
+
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/users"))
+	assert.Empty(t, *entries)
+
+	ctx.WithDebug(true).WithRedactedHeaders("x-tenant")
+	assert.Nil(t, ctx.ISetHeaderWithValue("Authorization", "Bearer token"))
+	assert.Nil(t, ctx.ISetHeaderWithValue("X-Tenant", "acme"))
+	assert.Nil(t, ctx.ISendRequestToWithBody("POST", "/users", &godog.DocString{Content: `{"name":"John"}`}))
+	assert.Len(t, *entries, 2)
+
+	request := (*entries)[0]
+	assert.Equal(t, LogLevelDebug, request.Level)
+	assert.Equal(t, "request", request.Message)
+	assert.Equal(t, "POST", request.Field("method"))
+	assert.Equal(t, "****", request.Field("headers").(http.Header).Get("Authorization"))
+	assert.Equal(t, "****", request.Field("headers").(http.Header).Get("X-Tenant"))
+	assert.Equal(t, "{\n  \"name\": \"John\"\n}", request.Field("body"))
+
+	response := (*entries)[1]
+	assert.Equal(t, "response", response.Message)
+	assert.Equal(t, 200, response.Field("status"))
+	assert.Equal(t, "****", response.Field("headers").(http.Header).Get("Set-Cookie"))
+	assert.Nil(t, response.Field("missing"))
+
+	// the request headers are not changed by the redaction
+	assert.Equal(t, "Bearer token", ctx.lastRequest.Header.Get("Authorization"))
+}
+
+func TestApiContext_WithLogBodyLimit(t *testing.T) {
+	ctx, entries, closeServer := setupLoggingTestContext()
+	defer closeServer()
+
+	ctx.WithLogLevel(LogLevelDebug).WithLogBodyLimit(5)
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/users"))
+	assert.Equal(t, "{\n  \"... (26 bytes truncated)", (*entries)[1].Field("body"))
+}
+
+func TestApiContext_WithLogOnFailure(t *testing.T) {
+	ctx, entries, closeServer := setupLoggingTestContext()
+	defer closeServer()
+
+	ctx.WithLogOnFailure(true)
+
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/users"))
+	ctx.flushLogs(false)
+	assert.Empty(t, *entries)
+
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/users"))
+	ctx.flushLogs(true)
+	assert.Len(t, *entries, 2)
+	assert.Equal(t, LogLevelError, (*entries)[0].Level)
+	assert.Equal(t, LogLevelError, (*entries)[1].Level)
+
+	ctx.flushLogs(true)
+	assert.Len(t, *entries, 2)
+}
+
+func TestStdLogger_Log(t *testing.T) {
+	var output bytes.Buffer
+	log.SetOutput(&output)
+	log.SetFlags(0)
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.LstdFlags)
+	}()
+
+	StdLogger{}.Log(LogEntry{
+		Level:   LogLevelDebug,
+		Message: "request",
+		Fields: []LogField{
+			{Key: "method", Value: "GET"},
+			{Key: "headers", Value: http.Header{"Accept": {"application/json"}}},
+			{Key: "body", Value: "{}"},
+		},
+	})
+
+	assert.Equal(t, "[DEBUG] request method=GET\nAccept: application/json\n\n{}\n", output.String())
+}
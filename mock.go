@@ -0,0 +1,270 @@
+package apicontext
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cucumber/godog"
+)
+
+// mockServer is a local HTTP server that answers with the responses stubbed in the scenario and records the received requests.
+type mockServer struct {
+	server *httptest.Server
+
+	mu       sync.Mutex
+	stubs    []mockStub
+	requests []MockRequest
+}
+
+// mockStub is the response of a mock server to the requests of a route.
+type mockStub struct {
+	method string
+	path   string
+	status int
+	body   string
+}
+
+// MockRequest is a request received by a mock server.
+type MockRequest struct {
+	Method string
+	Path   string
+	Query  string
+	Header http.Header
+	Body   string
+}
+
+// WithMockServer Starts a mock server with the specified name, to stub a downstream dependency of the tested service.
+// Use MockServerURL to configure the tested service to call it. The stubs and received requests are cleared before each scenario.
+func (ctx *ApiContext) WithMockServer(name string) *ApiContext {
+	if _, ok := ctx.mocks[name]; ok {
+		return ctx
+	}
+
+	mock := &mockServer{}
+	mock.server = httptest.NewServer(http.HandlerFunc(mock.serveHTTP))
+	ctx.mocks[name] = mock
+
+	return ctx
+}
+
+// MockServerURL returns the base URL of the mock server with the specified name, or an empty string if it does not exist.
+func (ctx *ApiContext) MockServerURL(name string) string {
+	mock, ok := ctx.mocks[name]
+
+	if !ok {
+		return ""
+	}
+
+	return mock.server.URL
+}
+
+// CloseMockServers stops all the mock servers. It should be called when the Test suite finishes.
+func (ctx *ApiContext) CloseMockServers() {
+	for name, mock := range ctx.mocks {
+		mock.server.Close()
+		delete(ctx.mocks, name)
+	}
+}
+
+// TheMockRespondsToWithStatus Stubs the response of the mock server to a route, like "POST /charge", with an empty body.
+// A route without method, like "/charge", matches requests with any method.
+func (ctx *ApiContext) TheMockRespondsToWithStatus(name string, route string, statusCode int) error {
+	return ctx.stubMock(name, route, statusCode, "")
+}
+
+// TheMockRespondsToWithStatusAndBody Stubs the response of the mock server to a route, like "POST /charge", with the DocString as body.
+func (ctx *ApiContext) TheMockRespondsToWithStatusAndBody(name string, route string, statusCode int, body *godog.DocString) error {
+	return ctx.stubMock(name, route, statusCode, ctx.ReplaceScopeVariables(body.Content))
+}
+
+// TheMockShouldHaveReceivedRequestsTo Checks the number of requests received by the mock server for a route, like "/charge" or "POST /charge".
+func (ctx *ApiContext) TheMockShouldHaveReceivedRequestsTo(name string, expectedCount int, route string) error {
+	mock, err := ctx.mock(name)
+
+	if err != nil {
+		return err
+	}
+
+	method, path := parseMockRoute(route)
+	count := 0
+	for _, req := range mock.receivedRequests() {
+		if (method == "" || req.Method == method) && req.Path == path {
+			count++
+		}
+	}
+
+	if count != expectedCount {
+		return fmt.Errorf("expected mock %s to have received %d requests to %s, but it received %d.\n Received requests: %s", name, expectedCount, route, count, mock.describeRequests())
+	}
+
+	return nil
+}
+
+// TheLastRequestToMockShouldHaveJSONPathWithValue Validates the value at the specified json path of the body of the last request received by the mock server.
+func (ctx *ApiContext) TheLastRequestToMockShouldHaveJSONPathWithValue(name string, pathExpr string, expectedValue string) error {
+	mock, err := ctx.mock(name)
+
+	if err != nil {
+		return err
+	}
+
+	requests := mock.receivedRequests()
+
+	if len(requests) == 0 {
+		return fmt.Errorf("the mock %s did not receive any request", name)
+	}
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(requests[len(requests)-1].Body), &data); err != nil {
+		return fmt.Errorf("the body of the last request to mock %s is not a valid json: %v", name, err)
+	}
+
+	value, found, err := ctx.evaluatePath(pathExpr, data)
+
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return fmt.Errorf("the json path %s was not present in the last request to mock %s", pathExpr, name)
+	}
+
+	expectedValue = ctx.ReplaceScopeVariables(expectedValue)
+	match, err := jsonValueEquals(value, expectedValue)
+
+	if err != nil {
+		return err
+	}
+
+	if !match {
+		return jsonPathError(pathExpr, fmt.Sprintf("to have value %s", expectedValue), value)
+	}
+
+	return nil
+}
+
+// resetMocks clears the stubs and received requests of all the mock servers.
+func (ctx *ApiContext) resetMocks() {
+	for _, mock := range ctx.mocks {
+		mock.mu.Lock()
+		mock.stubs = nil
+		mock.requests = nil
+		mock.mu.Unlock()
+	}
+}
+
+// stubMock registers the response of the mock server to a route. Later stubs take precedence over earlier ones.
+func (ctx *ApiContext) stubMock(name string, route string, statusCode int, body string) error {
+	mock, err := ctx.mock(name)
+
+	if err != nil {
+		return err
+	}
+
+	method, path := parseMockRoute(route)
+
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	mock.stubs = append(mock.stubs, mockStub{
+		method: method,
+		path:   path,
+		status: statusCode,
+		body:   body,
+	})
+
+	return nil
+}
+
+// mock returns the mock server with the specified name.
+func (ctx *ApiContext) mock(name string) (*mockServer, error) {
+	mock, ok := ctx.mocks[name]
+
+	if !ok {
+		return nil, fmt.Errorf("the mock %s does not exist. Register it with WithMockServer", name)
+	}
+
+	return mock, nil
+}
+
+// serveHTTP records the request and answers with the matching stub, or 404 if there is none.
+func (m *mockServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+
+	m.mu.Lock()
+	m.requests = append(m.requests, MockRequest{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.RawQuery,
+		Header: r.Header.Clone(),
+		Body:   string(body),
+	})
+
+	var stub *mockStub
+	for i := len(m.stubs) - 1; i >= 0; i-- {
+		if (m.stubs[i].method == "" || m.stubs[i].method == r.Method) && m.stubs[i].path == r.URL.Path {
+			stub = &m.stubs[i]
+			break
+		}
+	}
+	m.mu.Unlock()
+
+	if stub == nil {
+		http.Error(w, fmt.Sprintf("no stub for %s %s", r.Method, r.URL.Path), http.StatusNotFound)
+		return
+	}
+
+	if json.Valid([]byte(stub.body)) {
+		w.Header().Set("Content-Type", "application/json")
+	}
+
+	w.WriteHeader(stub.status)
+	_, _ = w.Write([]byte(stub.body))
+}
+
+// receivedRequests returns a copy of the requests received by the mock server.
+func (m *mockServer) receivedRequests() []MockRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	requests := make([]MockRequest, len(m.requests))
+	copy(requests, m.requests)
+
+	return requests
+}
+
+// describeRequests summarizes the received requests for the error messages.
+func (m *mockServer) describeRequests() string {
+	counts := map[string]int{}
+	for _, req := range m.receivedRequests() {
+		counts[req.Method+" "+req.Path]++
+	}
+
+	if len(counts) == 0 {
+		return "none"
+	}
+
+	var routes []string
+	for route, count := range counts {
+		routes = append(routes, fmt.Sprintf("%s (%d)", route, count))
+	}
+	sort.Strings(routes)
+
+	return strings.Join(routes, ", ")
+}
+
+// parseMockRoute splits a route like "POST /charge" in its method and path. The method is optional.
+func parseMockRoute(route string) (string, string) {
+	parts := strings.Fields(route)
+
+	if len(parts) == 2 {
+		return strings.ToUpper(parts[0]), parts[1]
+	}
+
+	return "", strings.TrimSpace(route)
+}
@@ -0,0 +1,73 @@
+package apicontext
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiContext_MockServer(t *testing.T) {
+	ctx := setupTestContext().
+		WithDebug(false).
+		WithMockServer("payments")
+	defer ctx.CloseMockServers()
+
+	url := ctx.MockServerURL("payments")
+	assert.NotEmpty(t, url)
+	assert.Empty(t, ctx.MockServerURL("missing"))
+
+	assert.Nil(t, ctx.StoreScopeData("chargeId", "ch_1"))
+	assert.Nil(t, ctx.TheMockRespondsToWithStatusAndBody("payments", "POST /charge", 201, &godog.DocString{
+		Content: "{\"id\": \"`##chargeId`\"}",
+	}))
+	assert.Nil(t, ctx.TheMockRespondsToWithStatus("payments", "/health", 204))
+	assert.Error(t, ctx.TheMockRespondsToWithStatus("missing", "/health", 204))
+
+	resp, err := http.Post(url+"/charge", "application/json", bytes.NewBufferString(`{"amount": 100, "currency": "EUR"}`))
+	assert.Nil(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	assert.Equal(t, 201, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.JSONEq(t, `{"id": "ch_1"}`, string(body))
+
+	resp, err = http.Get(url + "/charge")
+	assert.Nil(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, 404, resp.StatusCode)
+
+	resp, err = http.Get(url + "/health")
+	assert.Nil(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, 204, resp.StatusCode)
+
+	assert.Nil(t, ctx.TheMockShouldHaveReceivedRequestsTo("payments", 2, "/charge"))
+	assert.Nil(t, ctx.TheMockShouldHaveReceivedRequestsTo("payments", 1, "POST /charge"))
+	assert.Nil(t, ctx.TheMockShouldHaveReceivedRequestsTo("payments", 0, "/refund"))
+	assert.EqualError(t, ctx.TheMockShouldHaveReceivedRequestsTo("payments", 2, "/health"), "expected mock payments to have received 2 requests to /health, but it received 1.\n Received requests: GET /charge (1), GET /health (1), POST /charge (1)")
+}
+
+func TestApiContext_TheLastRequestToMockShouldHaveJSONPathWithValue(t *testing.T) {
+	ctx := setupTestContext().
+		WithDebug(false).
+		WithMockServer("payments")
+	defer ctx.CloseMockServers()
+
+	assert.Error(t, ctx.TheLastRequestToMockShouldHaveJSONPathWithValue("payments", "$.amount", "100"))
+
+	resp, err := http.Post(ctx.MockServerURL("payments")+"/charge", "application/json", bytes.NewBufferString(`{"amount": 100, "currency": "EUR"}`))
+	assert.Nil(t, err)
+	_ = resp.Body.Close()
+
+	assert.Nil(t, ctx.TheLastRequestToMockShouldHaveJSONPathWithValue("payments", "$.amount", "100"))
+	assert.Nil(t, ctx.TheLastRequestToMockShouldHaveJSONPathWithValue("payments", "pointer:/currency", "EUR"))
+	assert.Error(t, ctx.TheLastRequestToMockShouldHaveJSONPathWithValue("payments", "$.currency", "USD"))
+	assert.Error(t, ctx.TheLastRequestToMockShouldHaveJSONPathWithValue("payments", "$.missing", "USD"))
+
+	ctx.reset(nil)
+	assert.Nil(t, ctx.TheMockShouldHaveReceivedRequestsTo("payments", 0, "/charge"))
+}
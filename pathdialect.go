@@ -0,0 +1,120 @@
+package apicontext
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/PaesslerAG/jsonpath"
+	"github.com/jmespath/go-jmespath"
+	"github.com/xeipuuv/gojsonpointer"
+)
+
+// PathDialect defines the query language used to evaluate the paths of the json path steps.
+type PathDialect string
+
+const (
+	// JSONPathDialect evaluates paths like "$.a.b" using PaesslerAG/jsonpath. This is the default dialect.
+	JSONPathDialect PathDialect = "jsonpath"
+
+	// JSONPointerDialect evaluates RFC 6901 JSON Pointers like "/a/b/0".
+	JSONPointerDialect PathDialect = "pointer"
+
+	// JMESPathDialect evaluates JMESPath expressions like "items[?status=='ok'].id".
+	// JMESPath does not distinguish a missing value from null, so a null result is reported as not present.
+	JMESPathDialect PathDialect = "jmes"
+)
+
+// pathDialects lists the dialects that can be used as a prefix of a path, as in "pointer:/a/b".
+var pathDialects = []PathDialect{JSONPathDialect, JSONPointerDialect, JMESPathDialect}
+
+// WithPathDialect Configures the default dialect used to evaluate the paths of the json path steps.
+// A single step can still use another dialect by prefixing the path with the dialect name, like "jmes:items[0].id".
+func (ctx *ApiContext) WithPathDialect(dialect PathDialect) *ApiContext {
+	ctx.pathDialect = dialect
+	return ctx
+}
+
+// resolvePathDialect returns the dialect of the path and the expression without the dialect prefix.
+func (ctx *ApiContext) resolvePathDialect(pathExpr string) (PathDialect, string) {
+	for _, dialect := range pathDialects {
+		prefix := string(dialect) + ":"
+		if strings.HasPrefix(pathExpr, prefix) {
+			return dialect, strings.TrimPrefix(pathExpr, prefix)
+		}
+	}
+
+	return ctx.pathDialect, pathExpr
+}
+
+// evaluatePath evaluates the path against the decoded document using its dialect.
+// A path that cannot be resolved in the document is reported as not found, while an invalid expression is an error.
+func (ctx *ApiContext) evaluatePath(pathExpr string, data interface{}) (interface{}, bool, error) {
+	dialect, expr := ctx.resolvePathDialect(pathExpr)
+
+	switch dialect {
+	case JSONPointerDialect:
+		pointer, err := gojsonpointer.NewJsonPointer(expr)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid json pointer %s: %v", expr, err)
+		}
+
+		value, _, err := pointer.Get(data)
+		if err != nil {
+			return nil, false, nil
+		}
+
+		return value, true, nil
+	case JMESPathDialect:
+		query, err := jmespath.Compile(expr)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid jmespath expression %s: %v", expr, err)
+		}
+
+		value, err := query.Search(data)
+		if err != nil || value == nil {
+			return nil, false, nil
+		}
+
+		return value, true, nil
+	case JSONPathDialect, "":
+		eval, err := jsonpath.New(expr)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid json path %s: %v", expr, err)
+		}
+
+		value, err := eval(context.Background(), data)
+		if err != nil {
+			return nil, false, nil
+		}
+
+		return value, true, nil
+	default:
+		return nil, false, fmt.Errorf("unknown path dialect %s", dialect)
+	}
+}
+
+// relativePath turns a field name into a path relative to an array element, using the dialect of the context.
+// Fields that already are a path of the dialect, or have a dialect prefix, are returned as is.
+func (ctx *ApiContext) relativePath(field string) string {
+	dialect, expr := ctx.resolvePathDialect(field)
+
+	if expr != field {
+		return field
+	}
+
+	switch dialect {
+	case JSONPointerDialect:
+		if !strings.HasPrefix(field, "/") {
+			return "/" + field
+		}
+	case JMESPathDialect:
+		return field
+	default:
+		if !strings.HasPrefix(field, "$") {
+			return "$." + field
+		}
+	}
+
+	return field
+}
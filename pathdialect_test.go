@@ -0,0 +1,49 @@
+package apicontext
+
+import (
+	"testing"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiContext_WithPathDialect(t *testing.T) {
+	ctx := setupTestContext()
+	assert.Equal(t, JSONPathDialect, ctx.pathDialect)
+
+	ctx.WithPathDialect(JMESPathDialect)
+	assert.Equal(t, JMESPathDialect, ctx.pathDialect)
+}
+
+func TestApiContext_JSONPointerDialect(t *testing.T) {
+	ctx, teardown := setupResponseTestContext(t, "application/json", readTestData(t, "test_json_path.json"))
+	defer teardown()
+
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("pointer:/object/key", "value"))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("pointer:/list/1", "item2"))
+	assert.Nil(t, ctx.TheJSONPathShouldBeNull("pointer:/e"))
+	assert.Nil(t, ctx.TheJSONPathShouldNotBePresent("pointer:/missing"))
+	assert.Error(t, ctx.TheJSONPathShouldBePresent("pointer:missing"))
+
+	ctx.WithPathDialect(JSONPointerDialect)
+	assert.Nil(t, ctx.TheJSONPathHaveCount("/list", 2))
+	assert.Error(t, ctx.TheJSONPathShouldHaveValue("$.a", "a"))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("jsonpath:$.a", "a"))
+}
+
+func TestApiContext_JMESPathDialect(t *testing.T) {
+	ctx, teardown := setupResponseTestContext(t, "application/json", readTestData(t, "test_json_items.json"))
+	defer teardown()
+
+	assert.Nil(t, ctx.TheJSONPathShouldHaveJSONValue("jmes:items[?type=='order'].id", &godog.DocString{Content: "[3, 1]"}))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("jmes:length(items)", "3"))
+	assert.Nil(t, ctx.TheJSONPathShouldNotBePresent("jmes:missing"))
+	assert.Error(t, ctx.TheJSONPathShouldBePresent("jmes:items[?"))
+
+	ctx.WithPathDialect(JMESPathDialect)
+	assert.Nil(t, ctx.EveryElementOfJSONPathShouldHave("items", "status", "equal to", "active"))
+	assert.Nil(t, ctx.TheJSONPathShouldBeSorted("items[*].id", "descending"))
+	assert.Nil(t, ctx.StoreJsonPathValue("items[0].type", "type"))
+	assert.Nil(t, ctx.TheScopeVariableShouldHaveValue("type", "order"))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("jsonpath:$.items[1].type", "refund"))
+}
@@ -0,0 +1,120 @@
+package apicontext
+
+import (
+	"net/url"
+	"strings"
+)
+
+// QueryArrayStyle defines how query params with multiple values are encoded.
+type QueryArrayStyle string
+
+const (
+	// QueryArrayRepeat repeats the param for each value, like tag=a&tag=b. It's the default style.
+	QueryArrayRepeat QueryArrayStyle = "repeat"
+	// QueryArrayBrackets repeats the param, with brackets after its name, for each value, like tag[]=a&tag[]=b.
+	QueryArrayBrackets QueryArrayStyle = "brackets"
+	// QueryArrayComma joins the values with commas, like tag=a,b.
+	QueryArrayComma QueryArrayStyle = "comma"
+)
+
+// queryParam is a query param set in the scenario. A param can have several values.
+type queryParam struct {
+	name  string
+	value string
+}
+
+// WithQueryArrayStyle Configures how the query params with multiple values are encoded.
+func (ctx *ApiContext) WithQueryArrayStyle(style QueryArrayStyle) *ApiContext {
+	ctx.queryArrayStyle = style
+	return ctx
+}
+
+// IAddQueryParamWithValue Adds a value to a query param, keeping its previous values, like tag=a&tag=b.
+func (ctx *ApiContext) IAddQueryParamWithValue(name string, value string) error {
+	ctx.queryParams = append(ctx.queryParams, queryParam{name: name, value: ctx.ReplaceScopeVariables(value)})
+	return nil
+}
+
+// ISetTheQueryStringTo Sets a query string that is added verbatim, without encoding, to the requests.
+func (ctx *ApiContext) ISetTheQueryStringTo(query string) error {
+	ctx.rawQuery = strings.TrimPrefix(ctx.ReplaceScopeVariables(query), "?")
+	return nil
+}
+
+// setQueryParam replaces the values of a query param. The param keeps the position of its first value.
+func (ctx *ApiContext) setQueryParam(name string, value string) {
+	params := ctx.queryParams[:0]
+	replaced := false
+
+	for _, param := range ctx.queryParams {
+		if param.name != name {
+			params = append(params, param)
+			continue
+		}
+
+		if !replaced {
+			params = append(params, queryParam{name: name, value: value})
+			replaced = true
+		}
+	}
+
+	if !replaced {
+		params = append(params, queryParam{name: name, value: value})
+	}
+
+	ctx.queryParams = params
+}
+
+// queryParamValues returns the values of a query param.
+func (ctx *ApiContext) queryParamValues(name string) []string {
+	var values []string
+	for _, param := range ctx.queryParams {
+		if param.name == name {
+			values = append(values, param.value)
+		}
+	}
+
+	return values
+}
+
+// encodeQuery encodes the raw query string and the query params, in the order they were set, using the array style.
+func (ctx *ApiContext) encodeQuery() string {
+	var parts []string
+
+	if ctx.rawQuery != "" {
+		parts = append(parts, ctx.rawQuery)
+	}
+
+	var names []string
+	values := map[string][]string{}
+	for _, param := range ctx.queryParams {
+		if _, ok := values[param.name]; !ok {
+			names = append(names, param.name)
+		}
+		values[param.name] = append(values[param.name], url.QueryEscape(param.value))
+	}
+
+	for _, name := range names {
+		key := url.QueryEscape(name)
+
+		if len(values[name]) == 1 {
+			parts = append(parts, key+"="+values[name][0])
+			continue
+		}
+
+		switch ctx.queryArrayStyle {
+		case QueryArrayComma:
+			parts = append(parts, key+"="+strings.Join(values[name], ","))
+		case QueryArrayBrackets:
+			for _, value := range values[name] {
+				parts = append(parts, key+"[]="+value)
+			}
+		default:
+			for _, value := range values[name] {
+				parts = append(parts, key+"="+value)
+			}
+		}
+	}
+
+	return strings.Join(parts, "&")
+}
@@ -0,0 +1,66 @@
+package apicontext
+
+import (
+	"testing"
+
+	"github.com/cucumber/godog"
+	"github.com/cucumber/messages-go/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiContext_IAddQueryParamWithValue(t *testing.T) {
+	ctx := New("https://example.com")
+
+	assert.Nil(t, ctx.ISetQueryParamWithValue("sort", "name"))
+	assert.Nil(t, ctx.IAddQueryParamWithValue("tag", "a"))
+	assert.Nil(t, ctx.IAddQueryParamWithValue("tag", "b c"))
+	assert.Nil(t, ctx.IAddQueryParamWithValue("page", "1"))
+
+	reqURL, err := ctx.requestURL("/items?fields=id")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://example.com/items?fields=id&sort=name&tag=a&tag=b+c&page=1", reqURL)
+
+	assert.Nil(t, ctx.ISetQueryParamWithValue("tag", "d"))
+	assert.Nil(t, ctx.ISetQueryParamWithValue("sort", "date"))
+	assert.Equal(t, "sort=date&tag=d&page=1", ctx.encodeQuery())
+}
+
+func TestApiContext_WithQueryArrayStyle(t *testing.T) {
+	ctx := New("https://example.com")
+
+	assert.Nil(t, ctx.IAddQueryParamWithValue("tag", "a"))
+	assert.Nil(t, ctx.IAddQueryParamWithValue("tag", "b,c"))
+	assert.Nil(t, ctx.IAddQueryParamWithValue("page", "1"))
+
+	assert.Equal(t, "tag=a&tag=b%2Cc&page=1", ctx.encodeQuery())
+	assert.Equal(t, "tag[]=a&tag[]=b%2Cc&page=1", ctx.WithQueryArrayStyle(QueryArrayBrackets).encodeQuery())
+	assert.Equal(t, "tag=a,b%2Cc&page=1", ctx.WithQueryArrayStyle(QueryArrayComma).encodeQuery())
+}
+
+func TestApiContext_ISetTheQueryStringTo(t *testing.T) {
+	ctx := New("https://example.com")
+
+	assert.Nil(t, ctx.StoreScopeData("filter", "status:open"))
+	assert.Nil(t, ctx.ISetTheQueryStringTo("?filter=`##filter`&flag"))
+	assert.Nil(t, ctx.ISetQueryParamWithValue("page", "2"))
+
+	reqURL, err := ctx.requestURL("/issues")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://example.com/issues?filter=status:open&flag&page=2", reqURL)
+
+	ctx.reset(nil)
+	assert.Equal(t, "", ctx.encodeQuery())
+}
+
+func TestApiContext_ISetQueryParamsToWithRepeatedRows(t *testing.T) {
+	ctx := New("https://example.com")
+
+	assert.Nil(t, ctx.ISetQueryParamsTo(&godog.Table{
+		Rows: []*messages.PickleStepArgument_PickleTable_PickleTableRow{
+			{Cells: []*messages.PickleStepArgument_PickleTable_PickleTableRow_PickleTableCell{{Value: "tag"}, {Value: "a"}}},
+			{Cells: []*messages.PickleStepArgument_PickleTable_PickleTableRow_PickleTableCell{{Value: "tag"}, {Value: "b"}}},
+		},
+	}))
+
+	assert.Equal(t, []string{"a", "b"}, ctx.queryParamValues("tag"))
+}
@@ -0,0 +1,465 @@
+package apicontext
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cucumber/godog"
+)
+
+// link is a link of the Link response header, like <https://api.example.com/users?page=2>; rel="next".
+type link struct {
+	url    string
+	rels   []string
+	params map[string]string
+}
+
+// TheResponseHeaderShouldBePresent Checks that the response has the header.
+func (ctx *ApiContext) TheResponseHeaderShouldBePresent(name string) error {
+	_, err := ctx.requiredResponseHeader(name)
+	return err
+}
+
+// TheResponseHeaderShouldNotBePresent Checks that the response doesn't have the header.
+func (ctx *ApiContext) TheResponseHeaderShouldNotBePresent(name string) error {
+	values, ok, err := ctx.responseHeaderValues(name)
+
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		return responseHeaderError(name, "not to be present", values)
+	}
+
+	return nil
+}
+
+// TheResponseHeaderShouldMatch Checks that the value of the response header matches the regular expression.
+func (ctx *ApiContext) TheResponseHeaderShouldMatch(name string, pattern string) error {
+	values, err := ctx.requiredResponseHeader(name)
+
+	if err != nil {
+		return err
+	}
+
+	re, err := regexp.Compile(ctx.ReplaceScopeVariables(pattern))
+
+	if err != nil {
+		return fmt.Errorf("invalid pattern %s: %v", pattern, err)
+	}
+
+	if !re.MatchString(strings.Join(values, ", ")) {
+		return responseHeaderError(name, "to match "+pattern, values)
+	}
+
+	return nil
+}
+
+// TheResponseHeaderShouldContain Checks that the value of the response header contains the text.
+func (ctx *ApiContext) TheResponseHeaderShouldContain(name string, text string) error {
+	values, err := ctx.requiredResponseHeader(name)
+
+	if err != nil {
+		return err
+	}
+
+	expected := ctx.ReplaceScopeVariables(text)
+
+	if !strings.Contains(strings.Join(values, ", "), expected) {
+		return responseHeaderError(name, "to contain "+expected, values)
+	}
+
+	return nil
+}
+
+// TheResponseHeaderShouldHaveValues Checks the values of a multi-value response header, like "GET, POST".
+// The values can be sent in several header lines or in a single line separated by commas.
+func (ctx *ApiContext) TheResponseHeaderShouldHaveValues(name string, expected string) error {
+	values, err := ctx.requiredResponseHeader(name)
+
+	if err != nil {
+		return err
+	}
+
+	actual := splitHeaderValues(values)
+	expectedValues := splitHeaderValues([]string{ctx.ReplaceScopeVariables(expected)})
+
+	if strings.Join(actual, ", ") != strings.Join(expectedValues, ", ") {
+		return responseHeaderError(name, "to have the values "+strings.Join(expectedValues, ", "), values)
+	}
+
+	return nil
+}
+
+// TheResponseHeaderShouldBeGreaterThan Checks that the response header is a number greater than the expected value.
+func (ctx *ApiContext) TheResponseHeaderShouldBeGreaterThan(name string, expected float64) error {
+	actual, err := ctx.responseHeaderNumber(name)
+
+	if err != nil {
+		return err
+	}
+
+	if actual <= expected {
+		return responseHeaderError(name, fmt.Sprintf("to be greater than %v", expected), []string{fmt.Sprint(actual)})
+	}
+
+	return nil
+}
+
+// TheResponseHeaderShouldBeLessThan Checks that the response header is a number less than the expected value.
+func (ctx *ApiContext) TheResponseHeaderShouldBeLessThan(name string, expected float64) error {
+	actual, err := ctx.responseHeaderNumber(name)
+
+	if err != nil {
+		return err
+	}
+
+	if actual >= expected {
+		return responseHeaderError(name, fmt.Sprintf("to be less than %v", expected), []string{fmt.Sprint(actual)})
+	}
+
+	return nil
+}
+
+// TheResponseHeaderShouldBeADateInThe Checks that the response header is an HTTP date in the future or in the past.
+func (ctx *ApiContext) TheResponseHeaderShouldBeADateInThe(name string, when string) error {
+	values, err := ctx.requiredResponseHeader(name)
+
+	if err != nil {
+		return err
+	}
+
+	date, err := http.ParseTime(values[0])
+
+	if err != nil {
+		return responseHeaderError(name, "to be an HTTP date", values)
+	}
+
+	now := time.Now()
+
+	if when == "future" && !date.After(now) || when == "past" && !date.Before(now) {
+		return responseHeaderError(name, "to be a date in the "+when, values)
+	}
+
+	return nil
+}
+
+// TheResponseShouldHaveALinkWithRel Checks that the Link response header has a link with the relation, like "next".
+func (ctx *ApiContext) TheResponseShouldHaveALinkWithRel(rel string) error {
+	_, err := ctx.responseLink(rel)
+	return err
+}
+
+// TheResponseShouldNotHaveALinkWithRel Checks that the Link response header doesn't have a link with the relation, like "next" in the last page.
+func (ctx *ApiContext) TheResponseShouldNotHaveALinkWithRel(rel string) error {
+	if _, err := ctx.responseHeader(); err != nil {
+		return err
+	}
+
+	if l, err := ctx.responseLink(rel); err == nil {
+		return fmt.Errorf("expected the response not to have a link with rel %s, but it links to %s", rel, l.url)
+	}
+
+	return nil
+}
+
+// TheLinkWithRelShouldHaveURL Checks the URL of a link of the Link response header. Relative URLs are resolved against the request URL.
+func (ctx *ApiContext) TheLinkWithRelShouldHaveURL(rel string, expectedURL string) error {
+	l, err := ctx.responseLink(rel)
+
+	if err != nil {
+		return err
+	}
+
+	expected := ctx.ReplaceScopeVariables(expectedURL)
+
+	if l.url != expected && ctx.resolveLinkURL(l.url) != expected {
+		return fmt.Errorf("expected the link with rel %s to have url %s. actual: %s", rel, expected, l.url)
+	}
+
+	return nil
+}
+
+// StoreLinkWithRel Stores the URL of a link of the Link response header in the scenario scope.
+// Relative URLs are resolved against the request URL.
+func (ctx *ApiContext) StoreLinkWithRel(rel string, key string) error {
+	l, err := ctx.responseLink(rel)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.StoreScopeData(key, ctx.resolveLinkURL(l.url))
+}
+
+// ISendRequestToTheLinkWithRel Sends a request to a link of the Link response header, like the next page.
+func (ctx *ApiContext) ISendRequestToTheLinkWithRel(method string, rel string) error {
+	l, err := ctx.responseLink(rel)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.ISendRequestTo(method, ctx.resolveLinkURL(l.url))
+}
+
+// TheResponseShouldHaveTheFollowingHeaders Evaluates a table of header | operator | value rows and reports all the failures at once.
+func (ctx *ApiContext) TheResponseShouldHaveTheFollowingHeaders(dt *godog.Table) error {
+	if _, err := ctx.responseHeader(); err != nil {
+		return err
+	}
+
+	var failures []string
+
+	for i := 0; i < len(dt.Rows); i++ {
+		cells := dt.Rows[i].Cells
+
+		if i == 0 && len(cells) > 1 && strings.EqualFold(cells[0].Value, "header") && strings.EqualFold(cells[1].Value, "operator") {
+			continue
+		}
+
+		if len(cells) < 2 {
+			failures = append(failures, fmt.Sprintf("row %d: expected at least the header and operator columns", i+1))
+			continue
+		}
+
+		value := ""
+		if len(cells) > 2 {
+			value = cells[2].Value
+		}
+
+		if err := ctx.assertResponseHeader(cells[0].Value, cells[1].Value, value); err != nil {
+			failures = append(failures, fmt.Sprintf("row %d: %v", i+1, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%d header assertion(s) failed:\n%s", len(failures), strings.Join(failures, "\n"))
+	}
+
+	return nil
+}
+
+// assertResponseHeader runs the header step matching the operator used in TheResponseShouldHaveTheFollowingHeaders.
+func (ctx *ApiContext) assertResponseHeader(name string, operator string, value string) error {
+	switch strings.ToLower(strings.TrimSpace(operator)) {
+	case "equals":
+		return ctx.TheResponseHeaderShouldHaveValue(name, value)
+	case "matches":
+		return ctx.TheResponseHeaderShouldMatch(name, value)
+	case "contains":
+		return ctx.TheResponseHeaderShouldContain(name, value)
+	case "values":
+		return ctx.TheResponseHeaderShouldHaveValues(name, value)
+	case "present":
+		return ctx.TheResponseHeaderShouldBePresent(name)
+	case "absent":
+		return ctx.TheResponseHeaderShouldNotBePresent(name)
+	case "greater than", "less than":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %s for header %s", value, name)
+		}
+		if strings.EqualFold(strings.TrimSpace(operator), "greater than") {
+			return ctx.TheResponseHeaderShouldBeGreaterThan(name, n)
+		}
+		return ctx.TheResponseHeaderShouldBeLessThan(name, n)
+	default:
+		return fmt.Errorf("unknown header operator %s", operator)
+	}
+}
+
+// responseHeader returns the headers of the last response, or an error when no request was sent.
+func (ctx *ApiContext) responseHeader() (http.Header, error) {
+	if ctx.lastResponse == nil || ctx.lastResponse.ResponseObj == nil {
+		return nil, errors.New("no response available. Send a request first")
+	}
+
+	return ctx.lastResponse.ResponseObj.Header, nil
+}
+
+// responseHeaderValues returns the values of a response header and if the response has it.
+func (ctx *ApiContext) responseHeaderValues(name string) ([]string, bool, error) {
+	header, err := ctx.responseHeader()
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	values, ok := header[http.CanonicalHeaderKey(name)]
+	return values, ok, nil
+}
+
+// requiredResponseHeader returns the values of a response header, or an error when the response doesn't have it.
+func (ctx *ApiContext) requiredResponseHeader(name string) ([]string, error) {
+	values, ok, err := ctx.responseHeaderValues(name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("expected the response to have the header %s", name)
+	}
+
+	return values, nil
+}
+
+// responseHeaderNumber returns the value of a response header as a number, like the Content-Length.
+func (ctx *ApiContext) responseHeaderNumber(name string) (float64, error) {
+	values, err := ctx.requiredResponseHeader(name)
+
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(values[0]), 64)
+
+	if err != nil {
+		return 0, responseHeaderError(name, "to be a number", values)
+	}
+
+	return n, nil
+}
+
+// responseHeaderError builds the error returned by the header steps when an expectation is not met.
+func responseHeaderError(name string, expectation string, actual []string) error {
+	return fmt.Errorf("expected header %s %s, but it is %s", name, expectation, strings.Join(actual, ", "))
+}
+
+// splitHeaderValues splits the comma separated values of the header lines, trimming the spaces around them.
+func splitHeaderValues(lines []string) []string {
+	var values []string
+
+	for _, line := range lines {
+		for _, value := range strings.Split(line, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+
+	return values
+}
+
+// responseLink returns the link of the Link response header with the relation.
+func (ctx *ApiContext) responseLink(rel string) (link, error) {
+	values, _, err := ctx.responseHeaderValues("Link")
+
+	if err != nil {
+		return link{}, err
+	}
+
+	for _, l := range parseLinkHeader(values) {
+		for _, r := range l.rels {
+			if strings.EqualFold(r, rel) {
+				return l, nil
+			}
+		}
+	}
+
+	return link{}, fmt.Errorf("expected the response to have a link with rel %s. Link header: %s", rel, strings.Join(values, ", "))
+}
+
+// resolveLinkURL resolves a link URL against the URL of the last request.
+func (ctx *ApiContext) resolveLinkURL(linkURL string) string {
+	ref, err := url.Parse(linkURL)
+
+	if err != nil || ctx.lastResponse.ResponseObj.Request == nil {
+		return linkURL
+	}
+
+	return ctx.lastResponse.ResponseObj.Request.URL.ResolveReference(ref).String()
+}
+
+// parseLinkHeader parses the links of Link header lines, as defined in RFC 8288.
+// A relation can have several space separated types, like rel="last index".
+func parseLinkHeader(lines []string) []link {
+	var links []link
+
+	for _, line := range lines {
+		rest := line
+
+		for {
+			start := strings.Index(rest, "<")
+			if start < 0 {
+				break
+			}
+
+			end := strings.Index(rest[start:], ">")
+			if end < 0 {
+				break
+			}
+
+			l := link{url: rest[start+1 : start+end], params: map[string]string{}}
+			rest = rest[start+end+1:]
+
+			for {
+				rest = strings.TrimLeft(rest, " \t")
+				if !strings.HasPrefix(rest, ";") {
+					break
+				}
+
+				var name, value string
+				name, value, rest = parseLinkParam(rest[1:])
+				l.params[name] = value
+			}
+
+			l.rels = strings.Fields(l.params["rel"])
+			links = append(links, l)
+		}
+	}
+
+	return links
+}
+
+// parseLinkParam parses a link param, like rel="next", returning its name, its unquoted value and the rest of the header.
+func parseLinkParam(s string) (string, string, string) {
+	s = strings.TrimLeft(s, " \t")
+
+	end := strings.IndexAny(s, "=;,")
+	if end < 0 {
+		return strings.ToLower(strings.TrimSpace(s)), "", ""
+	}
+
+	name := strings.ToLower(strings.TrimSpace(s[:end]))
+	if s[end] != '=' {
+		return name, "", s[end:]
+	}
+
+	s = strings.TrimLeft(s[end+1:], " \t")
+
+	if strings.HasPrefix(s, `"`) {
+		var value strings.Builder
+
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				if i+1 < len(s) {
+					i++
+					value.WriteByte(s[i])
+				}
+			case '"':
+				return name, value.String(), s[i+1:]
+			default:
+				value.WriteByte(s[i])
+			}
+		}
+
+		return name, value.String(), ""
+	}
+
+	end = strings.IndexAny(s, ";,")
+	if end < 0 {
+		return name, strings.TrimSpace(s), ""
+	}
+
+	return name, strings.TrimSpace(s[:end]), s[end:]
+}
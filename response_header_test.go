@@ -0,0 +1,183 @@
+package apicontext
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/cucumber/messages-go/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupResponseHeadersTestServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-123")
+		w.Header().Add("Allow", "GET, HEAD")
+		w.Header().Add("Allow", "POST")
+		w.Header().Set("X-Rate-Limit-Remaining", "42")
+		w.Header().Set("Expires", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		w.Header().Set("Last-Modified", "Wed, 21 Oct 2015 07:28:00 GMT")
+		w.Header().Set("Link", `</users?page=3>; rel="next", <https://api.example.com/users?page=1>; rel="first prev"; title="a, \"b\"", <https://api.example.com/users?page=9>; rel=last`)
+		_, _ = w.Write([]byte("hello"))
+	}))
+}
+
+func TestApiContext_ResponseHeaderAssertions(t *testing.T) {
+	ts := setupResponseHeadersTestServer()
+	defer ts.Close()
+
+	ctx := New(ts.URL)
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/users?page=2"))
+
+	assert.Nil(t, ctx.TheResponseHeaderShouldBePresent("x-request-id"))
+	assert.EqualError(t, ctx.TheResponseHeaderShouldBePresent("X-Missing"), "expected the response to have the header X-Missing")
+	assert.Nil(t, ctx.TheResponseHeaderShouldNotBePresent("X-Missing"))
+	assert.EqualError(t, ctx.TheResponseHeaderShouldNotBePresent("X-Request-Id"), "expected header X-Request-Id not to be present, but it is req-123")
+
+	assert.Nil(t, ctx.TheResponseHeaderShouldMatch("X-Request-Id", `^req-\d+$`))
+	assert.Error(t, ctx.TheResponseHeaderShouldMatch("X-Request-Id", `^\d+$`))
+	assert.Error(t, ctx.TheResponseHeaderShouldMatch("X-Request-Id", `(`))
+	assert.Nil(t, ctx.TheResponseHeaderShouldContain("X-Request-Id", "123"))
+	assert.EqualError(t, ctx.TheResponseHeaderShouldContain("X-Missing", "123"), "expected the response to have the header X-Missing")
+
+	assert.Nil(t, ctx.TheResponseHeaderShouldHaveValues("Allow", "GET, HEAD, POST"))
+	assert.Nil(t, ctx.TheResponseHeaderShouldHaveValues("Allow", "GET,HEAD,POST"))
+	assert.EqualError(t, ctx.TheResponseHeaderShouldHaveValues("Allow", "GET, POST"), "expected header Allow to have the values GET, POST, but it is GET, HEAD, POST")
+
+	assert.Nil(t, ctx.TheResponseHeaderShouldBeGreaterThan("X-Rate-Limit-Remaining", 10))
+	assert.Nil(t, ctx.TheResponseHeaderShouldBeLessThan("Content-Length", 10))
+	assert.EqualError(t, ctx.TheResponseHeaderShouldBeLessThan("Content-Length", 5), "expected header Content-Length to be less than 5, but it is 5")
+	assert.EqualError(t, ctx.TheResponseHeaderShouldBeGreaterThan("X-Request-Id", 1), "expected header X-Request-Id to be a number, but it is req-123")
+
+	assert.Nil(t, ctx.TheResponseHeaderShouldBeADateInThe("Expires", "future"))
+	assert.Nil(t, ctx.TheResponseHeaderShouldBeADateInThe("Last-Modified", "past"))
+	assert.Error(t, ctx.TheResponseHeaderShouldBeADateInThe("Last-Modified", "future"))
+	assert.EqualError(t, ctx.TheResponseHeaderShouldBeADateInThe("X-Request-Id", "future"), "expected header X-Request-Id to be an HTTP date, but it is req-123")
+}
+
+func TestApiContext_TheResponseHeaderShouldHaveValueWithScope(t *testing.T) {
+	ts := setupResponseHeadersTestServer()
+	defer ts.Close()
+
+	ctx := New(ts.URL)
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+	assert.Nil(t, ctx.StoreScopeData("id", "req-123"))
+
+	assert.Nil(t, ctx.TheResponseHeaderShouldHaveValue("X-Request-Id", "`##id`"))
+	assert.EqualError(t, ctx.TheResponseHeaderShouldHaveValue("X-Request-Id", "other"), "expected header X-Request-Id to have value other. actual : req-123")
+}
+
+func TestApiContext_TheResponseShouldHaveTheFollowingHeaders(t *testing.T) {
+	ts := setupResponseHeadersTestServer()
+	defer ts.Close()
+
+	ctx := New(ts.URL)
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+
+	row := func(values ...string) *messages.PickleStepArgument_PickleTable_PickleTableRow {
+		var cells []*messages.PickleStepArgument_PickleTable_PickleTableRow_PickleTableCell
+		for _, value := range values {
+			cells = append(cells, &messages.PickleStepArgument_PickleTable_PickleTableRow_PickleTableCell{Value: value})
+		}
+		return &messages.PickleStepArgument_PickleTable_PickleTableRow{Cells: cells}
+	}
+
+	assert.Nil(t, ctx.TheResponseShouldHaveTheFollowingHeaders(&godog.Table{Rows: []*messages.PickleStepArgument_PickleTable_PickleTableRow{
+		row("header", "operator", "value"),
+		row("X-Request-Id", "equals", "req-123"),
+		row("X-Request-Id", "matches", "^req-"),
+		row("Allow", "values", "GET, HEAD, POST"),
+		row("Allow", "contains", "HEAD"),
+		row("X-Rate-Limit-Remaining", "greater than", "0"),
+		row("Content-Length", "less than", "100"),
+		row("Link", "present"),
+		row("X-Missing", "absent"),
+	}}))
+
+	err := ctx.TheResponseShouldHaveTheFollowingHeaders(&godog.Table{Rows: []*messages.PickleStepArgument_PickleTable_PickleTableRow{
+		row("X-Request-Id", "equals", "other"),
+		row("X-Missing", "present"),
+		row("Content-Length", "less than", "many"),
+		row("Allow", "starts with", "GET"),
+		row("Allow"),
+	}})
+	assert.EqualError(t, err, "5 header assertion(s) failed:\n"+
+		"row 1: expected header X-Request-Id to have value other. actual : req-123\n"+
+		"row 2: expected the response to have the header X-Missing\n"+
+		"row 3: invalid number many for header Content-Length\n"+
+		"row 4: unknown header operator starts with\n"+
+		"row 5: expected at least the header and operator columns")
+}
+
+func TestApiContext_LinkHeader(t *testing.T) {
+	ts := setupResponseHeadersTestServer()
+	defer ts.Close()
+
+	ctx := New(ts.URL)
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/users?page=2"))
+
+	assert.Nil(t, ctx.TheResponseShouldHaveALinkWithRel("next"))
+	assert.Nil(t, ctx.TheResponseShouldHaveALinkWithRel("prev"))
+	assert.Nil(t, ctx.TheResponseShouldNotHaveALinkWithRel("self"))
+	assert.EqualError(t, ctx.TheResponseShouldNotHaveALinkWithRel("last"), "expected the response not to have a link with rel last, but it links to https://api.example.com/users?page=9")
+
+	assert.Nil(t, ctx.TheLinkWithRelShouldHaveURL("next", "/users?page=3"))
+	assert.Nil(t, ctx.TheLinkWithRelShouldHaveURL("next", ts.URL+"/users?page=3"))
+	assert.Nil(t, ctx.TheLinkWithRelShouldHaveURL("first", "https://api.example.com/users?page=1"))
+	assert.Error(t, ctx.TheLinkWithRelShouldHaveURL("next", "/users?page=4"))
+
+	assert.Nil(t, ctx.StoreLinkWithRel("next", "next"))
+	assert.Nil(t, ctx.TheScopeVariableShouldHaveValue("next", ts.URL+"/users?page=3"))
+	assert.Error(t, ctx.StoreLinkWithRel("self", "self"))
+
+	assert.Nil(t, ctx.ISendRequestToTheLinkWithRel("GET", "next"))
+	assert.Equal(t, "/users?page=3", ctx.lastResponse.ResponseObj.Request.URL.RequestURI())
+	assert.Error(t, ctx.ISendRequestToTheLinkWithRel("GET", "self"))
+}
+
+func TestParseLinkHeader(t *testing.T) {
+	links := parseLinkHeader([]string{
+		`<https://example.com/a>; rel="next"; title="a, <b>; c", <https://example.com/b>;rel=prev`,
+		`<https://example.com/c>; rel="last index"`,
+	})
+
+	assert.Len(t, links, 3)
+	assert.Equal(t, "https://example.com/a", links[0].url)
+	assert.Equal(t, []string{"next"}, links[0].rels)
+	assert.Equal(t, "a, <b>; c", links[0].params["title"])
+	assert.Equal(t, "https://example.com/b", links[1].url)
+	assert.Equal(t, []string{"prev"}, links[1].rels)
+	assert.Equal(t, []string{"last", "index"}, links[2].rels)
+}
+
+func TestApiContext_ResponseHeaderStepsWithoutResponse(t *testing.T) {
+	ctx := New("https://example.com")
+
+	steps := map[string]func() error{
+		"header present":     func() error { return ctx.TheResponseHeaderShouldBePresent("X-Request-Id") },
+		"header not present": func() error { return ctx.TheResponseHeaderShouldNotBePresent("X-Request-Id") },
+		"header matches":     func() error { return ctx.TheResponseHeaderShouldMatch("X-Request-Id", ".*") },
+		"header value":       func() error { return ctx.TheResponseHeaderShouldHaveValue("X-Request-Id", "1") },
+		"store header":       func() error { return ctx.StoreResponseHeader("X-Request-Id", "requestId") },
+		"headers table":      func() error { return ctx.TheResponseShouldHaveTheFollowingHeaders(&godog.Table{}) },
+		"no link":            func() error { return ctx.TheResponseShouldNotHaveALinkWithRel("next") },
+		"content type":       func() error { return ctx.TheResponseContentTypeShouldBe("application/json") },
+		"allow origin":       func() error { return ctx.TheResponseShouldAllowTheOrigin("https://app.example.com") },
+		"not allow origin":   func() error { return ctx.TheResponseShouldNotAllowTheOrigin("https://app.example.com") },
+		"allow method":       func() error { return ctx.TheResponseShouldAllowTheMethod("GET") },
+		"allow credentials":  func() error { return ctx.TheResponseShouldAllowCredentials() },
+		"cors max age":       func() error { return ctx.TheCORSMaxAgeShouldBeAtLeastSeconds(60) },
+		"cacheable":          func() error { return ctx.TheResponseShouldBeCacheableForAtLeastSeconds(60) },
+		"not cacheable":      func() error { return ctx.TheResponseShouldNotBeCacheable() },
+		"cache control":      func() error { return ctx.TheResponseCacheControlShouldInclude("public") },
+		"vary":               func() error { return ctx.TheResponseShouldVaryBy("Accept") },
+		"age":                func() error { return ctx.TheResponseAgeShouldBeAtMostSeconds(60) },
+		"cache validators":   func() error { return ctx.IStoreTheCacheValidatorsOfTheResponse() },
+	}
+
+	for name, step := range steps {
+		assert.EqualError(t, step(), "no response available. Send a request first", name)
+	}
+}
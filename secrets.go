@@ -0,0 +1,138 @@
+package apicontext
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// The text that replaces the secrets.
+const secretMask = "****"
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// WithSecret Registers values, like passwords or tokens, that are replaced by **** in the step errors, logs, curl commands,
+// HAR files and cassettes.
+func (ctx *ApiContext) WithSecret(values ...string) *ApiContext {
+	for _, value := range values {
+		if !ctx.isSecret(value) {
+			ctx.secrets = append(ctx.secrets, value)
+		}
+	}
+
+	return ctx
+}
+
+// isSecret checks if the value was registered as secret.
+func (ctx *ApiContext) isSecret(value string) bool {
+	for _, secret := range ctx.secrets {
+		if secret == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WithSecretScopeVariables Marks scope variables as secret, so the values stored in them are masked like the values registered with WithSecret.
+func (ctx *ApiContext) WithSecretScopeVariables(names ...string) *ApiContext {
+	for _, name := range names {
+		ctx.secretScopeVariables[name] = true
+	}
+
+	return ctx
+}
+
+// hasSecrets checks if any secret is configured.
+func (ctx *ApiContext) hasSecrets() bool {
+	return len(ctx.secrets) > 0 || len(ctx.secretScopeVariables) > 0
+}
+
+// maskSecrets replaces the secrets, and the current values of the secret scope variables, in the text.
+func (ctx *ApiContext) maskSecrets(text string) string {
+	if !ctx.hasSecrets() {
+		return text
+	}
+
+	values := make([]string, 0, len(ctx.secrets)+len(ctx.secretScopeVariables))
+	values = append(values, ctx.secrets...)
+	for name := range ctx.secretScopeVariables {
+		values = append(values, ctx.scope[name])
+	}
+
+	// longer secrets first, so a secret containing another one is fully masked.
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+
+		text = strings.Replace(text, value, secretMask, -1)
+
+		if escaped, err := json.Marshal(value); err == nil {
+			text = strings.Replace(text, strings.Trim(string(escaped), `"`), secretMask, -1)
+		}
+	}
+
+	return text
+}
+
+// maskHeaderSecrets returns a copy of the headers with the secrets masked.
+func (ctx *ApiContext) maskHeaderSecrets(header http.Header) http.Header {
+	masked := header.Clone()
+
+	for _, values := range masked {
+		for i := range values {
+			values[i] = ctx.maskSecrets(values[i])
+		}
+	}
+
+	return masked
+}
+
+// maskLogEntry returns a copy of the log entry with the secrets masked in the message and fields.
+func (ctx *ApiContext) maskLogEntry(entry LogEntry) LogEntry {
+	if !ctx.hasSecrets() {
+		return entry
+	}
+
+	fields := make([]LogField, len(entry.Fields))
+	for i, field := range entry.Fields {
+		switch value := field.Value.(type) {
+		case string:
+			field.Value = ctx.maskSecrets(value)
+		case http.Header:
+			field.Value = ctx.maskHeaderSecrets(value)
+		}
+		fields[i] = field
+	}
+
+	entry.Message = ctx.maskSecrets(entry.Message)
+	entry.Fields = fields
+
+	return entry
+}
+
+// maskStepErrors wraps the step function, so the secrets are masked in the returned error.
+// The secrets are checked when the step runs, so the ones registered after the steps, like the profile and environment secrets, are masked too.
+func (ctx *ApiContext) maskStepErrors(step interface{}) interface{} {
+	fn := reflect.ValueOf(step)
+
+	if fn.Kind() != reflect.Func || fn.Type().NumOut() != 1 || fn.Type().Out(0) != errorType {
+		return step
+	}
+
+	return reflect.MakeFunc(fn.Type(), func(args []reflect.Value) []reflect.Value {
+		out := fn.Call(args)
+
+		if err, ok := out[0].Interface().(error); ok && err != nil && ctx.hasSecrets() {
+			out[0] = reflect.ValueOf(errors.New(ctx.maskSecrets(err.Error()))).Convert(errorType)
+		}
+
+		return out
+	}).Interface()
+}
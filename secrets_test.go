@@ -0,0 +1,70 @@
+package apicontext
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiContext_WithSecret(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error": "invalid token s3cr3t", "session": "abc\"def"}`))
+	}))
+	defer ts.Close()
+
+	var entries []LogEntry
+	ctx := New(ts.URL).
+		WithSecret("s3cr3t", `abc"def`).
+		WithSecretScopeVariables("token").
+		WithDebug(true).
+		WithLogger(LoggerFunc(func(entry LogEntry) {
+			entries = append(entries, entry)
+		}))
+
+	assert.Nil(t, ctx.StoreScopeData("token", "scope-token"))
+	assert.Nil(t, ctx.ISetHeaderWithValue("X-Token", "scope-token"))
+	assert.Nil(t, ctx.ISendRequestToWithBody("POST", "/login", &godog.DocString{Content: `{"password": "s3cr3t", "token": "` + "`##token`" + `"}`}))
+
+	assert.NotContains(t, ctx.LastRequestAsCurl(), "s3cr3t")
+	assert.Contains(t, ctx.LastRequestAsCurl(), `{"password": "****", "token": "****"}`)
+	assert.Equal(t, "****", entries[0].Field("headers").(http.Header).Get("X-Token"))
+	assert.Equal(t, "{\n  \"password\": \"****\",\n  \"token\": \"****\"\n}", entries[0].Field("body"))
+	assert.Equal(t, "{\n  \"error\": \"invalid token ****\",\n  \"session\": \"****\"\n}", entries[1].Field("body"))
+
+	step := ctx.maskStepErrors(ctx.TheResponseCodeShouldBe).(func(int) error)
+	assert.EqualError(t, step(200), `expected status code to be 200, but actual is 401.`+"\n"+` Response body: {"error": "invalid token ****", "session": "****"}`)
+	assert.Nil(t, step(401))
+
+	storeStep := ctx.maskStepErrors(ctx.StoreScopeData).(func(string, string) error)
+	assert.Nil(t, storeStep("other", "value"))
+}
+
+func TestApiContext_maskSecrets(t *testing.T) {
+	ctx := New("")
+	assert.Equal(t, "token value", ctx.maskSecrets("token value"))
+
+	ctx.WithSecret("token", "", "token value")
+	assert.Equal(t, "****, ****", ctx.maskSecrets("token value, token"))
+
+	ctx.WithSecretScopeVariables("password")
+	assert.Equal(t, "password", ctx.maskSecrets("password"))
+	assert.Nil(t, ctx.StoreScopeData("password", "pass"))
+	assert.Equal(t, "****word", ctx.maskSecrets("password"))
+}
+
+func TestApiContext_maskStepErrorsWithSecretsAddedLater(t *testing.T) {
+	ctx := New("")
+
+	step := ctx.maskStepErrors(func(value string) error {
+		return fmt.Errorf("invalid value %s", value)
+	}).(func(string) error)
+	assert.EqualError(t, step("s3cr3t"), "invalid value s3cr3t")
+
+	ctx.WithSecret("s3cr3t")
+	assert.EqualError(t, step("s3cr3t"), "invalid value ****")
+}
@@ -0,0 +1,199 @@
+package apicontext
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// service is a named base URL, with the headers sent in every request to it.
+type service struct {
+	baseURL string
+	headers map[string]string
+}
+
+// WithService Registers a named service, so requests can be sent to it with URIs like "auth:/token",
+// or by selecting it for the scenario with the step: I use service "auth".
+func (ctx *ApiContext) WithService(name string, baseURL string) *ApiContext {
+	setService(ctx.services, name, baseURL)
+	ctx.updateProfileBase(func(base *profileBase) {
+		setService(base.services, name, baseURL)
+	})
+
+	return ctx
+}
+
+// WithServiceHeader Configures a header, like the Authorization header, sent in every request to a service registered with WithService.
+// The headers set in the scenario take precedence over the service headers.
+func (ctx *ApiContext) WithServiceHeader(serviceName string, name string, value string) *ApiContext {
+	if svc, ok := ctx.services[serviceName]; ok {
+		svc.headers[name] = value
+	}
+
+	ctx.updateProfileBase(func(base *profileBase) {
+		if svc, ok := base.services[serviceName]; ok {
+			svc.headers[name] = value
+		}
+	})
+
+	return ctx
+}
+
+// setService registers a service, or changes the base URL of a registered service.
+func setService(services map[string]*service, name string, baseURL string) {
+	if svc, ok := services[name]; ok {
+		svc.baseURL = baseURL
+		return
+	}
+
+	services[name] = &service{baseURL: baseURL, headers: map[string]string{}}
+}
+
+// copyServices returns a copy of the services and their headers.
+func copyServices(services map[string]*service) map[string]*service {
+	copied := make(map[string]*service, len(services))
+
+	for name, svc := range services {
+		headers := make(map[string]string, len(svc.headers))
+		for header, value := range svc.headers {
+			headers[header] = value
+		}
+
+		copied[name] = &service{baseURL: svc.baseURL, headers: headers}
+	}
+
+	return copied
+}
+
+// IUseService Sends the next requests of the scenario, with relative URIs, to the specified service.
+func (ctx *ApiContext) IUseService(name string) error {
+	if _, ok := ctx.services[name]; !ok {
+		return fmt.Errorf("the service %s does not exist. Available services: %s", name, strings.Join(ctx.serviceNames(), ", "))
+	}
+
+	ctx.currentService = name
+	return nil
+}
+
+// resolveService returns the service of the URI, prefixed with the service name like "auth:/token", or the service
+// selected in the scenario, and the URI relative to the service. The service is nil for the default base URL.
+func (ctx *ApiContext) resolveService(uri string) (*service, string) {
+	if i := strings.Index(uri, ":"); i > 0 {
+		if svc, ok := ctx.services[uri[:i]]; ok {
+			return svc, uri[i+1:]
+		}
+	}
+
+	if svc, ok := ctx.services[ctx.currentService]; ok {
+		return svc, uri
+	}
+
+	return nil, uri
+}
+
+// requestURL returns the URL of a request to the URI, relative to its service or to the base URL,
+// with the path params and query params set in the scenario.
+func (ctx *ApiContext) requestURL(uri string) (string, error) {
+	svc, uri := ctx.resolveService(uri)
+
+	baseURL := ctx.baseURL
+	if svc != nil {
+		baseURL = svc.baseURL
+	}
+
+	uri, err := ctx.expandPathTemplate(uri)
+
+	if err != nil {
+		return "", err
+	}
+
+	u, err := joinURL(baseURL, uri)
+
+	if err != nil {
+		return "", err
+	}
+
+	if query := ctx.encodeQuery(); query != "" {
+		if u.RawQuery != "" {
+			query = u.RawQuery + "&" + query
+		}
+		u.RawQuery = query
+	}
+
+	return u.String(), nil
+}
+
+// requestHeader returns the headers of a request to the URI. The service headers take precedence over the profile
+// and default headers, and the headers set in the scenario take precedence over both.
+func (ctx *ApiContext) requestHeader(uri string) http.Header {
+	header := http.Header{}
+
+	for name, values := range ctx.headers {
+		if !ctx.scenarioHeaders[name] {
+			header[name] = append([]string(nil), values...)
+		}
+	}
+
+	if svc, _ := ctx.resolveService(uri); svc != nil {
+		for name, value := range svc.headers {
+			header.Set(name, value)
+		}
+	}
+
+	for name, values := range ctx.headers {
+		if ctx.scenarioHeaders[name] {
+			header[name] = append([]string(nil), values...)
+		}
+	}
+
+	for name := range ctx.removedHeaders {
+		header.Del(name)
+	}
+
+	// the HTTP client doesn't send the User-Agent header when it's empty.
+	if ctx.removedHeaders["User-Agent"] {
+		header["User-Agent"] = []string{""}
+	}
+
+	return header
+}
+
+// newRequest creates a request to the URI with the service and scenario headers.
+func (ctx *ApiContext) newRequest(method string, uri string, body io.Reader) (*http.Request, error) {
+	reqURL, err := ctx.requestURL(uri)
+
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(method, reqURL, body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for name, values := range ctx.requestHeader(uri) {
+		req.Header[name] = values
+	}
+
+	if err := ctx.applySpecialHeaders(req); err != nil {
+		return nil, err
+	}
+
+	ctx.applyDefaultAccept(req)
+
+	return req, nil
+}
+
+// serviceNames returns the names of the registered services, sorted.
+func (ctx *ApiContext) serviceNames() []string {
+	names := make([]string, 0, len(ctx.services))
+	for name := range ctx.services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
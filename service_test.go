@@ -0,0 +1,80 @@
+package apicontext
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupServiceTestServer(name string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"service": "` + name + `", "path": "` + r.URL.Path + `", "auth": "` + r.Header.Get("Authorization") + `"}`))
+	}))
+}
+
+func TestApiContext_WithService(t *testing.T) {
+	gateway := setupServiceTestServer("gateway")
+	defer gateway.Close()
+	auth := setupServiceTestServer("auth")
+	defer auth.Close()
+	admin := setupServiceTestServer("admin")
+	defer admin.Close()
+
+	ctx := New(gateway.URL).
+		WithService("auth", auth.URL).
+		WithService("admin", admin.URL).
+		WithServiceHeader("admin", "Authorization", "Bearer admin-token").
+		WithServiceHeader("missing", "Authorization", "Bearer token")
+
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/orders"))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.service", "gateway"))
+
+	assert.Nil(t, ctx.ISendRequestToWithBody("POST", "auth:/token", &godog.DocString{Content: `{}`}))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.service", "auth"))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.path", "/token"))
+
+	assert.Nil(t, ctx.IUseService("admin"))
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/users"))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.service", "admin"))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.auth", "Bearer admin-token"))
+
+	assert.Nil(t, ctx.ISetHeaderWithValue("Authorization", "Bearer user-token"))
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/users"))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.auth", "Bearer user-token"))
+
+	assert.Nil(t, ctx.ISendRequestTo("GET", "auth:/keys"))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.service", "auth"))
+
+	assert.EqualError(t, ctx.IUseService("billing"), "the service billing does not exist. Available services: admin, auth")
+
+	ctx.reset(nil)
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/orders"))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.service", "gateway"))
+}
+
+func TestApiContext_ServiceHeadersPrecedence(t *testing.T) {
+	gateway := setupServiceTestServer("gateway")
+	defer gateway.Close()
+	admin := setupServiceTestServer("admin")
+	defer admin.Close()
+
+	ctx := New(gateway.URL).
+		WithDefaultHeaders(map[string]string{"Authorization": "Bearer default-token"}).
+		WithService("admin", admin.URL).
+		WithServiceHeader("admin", "Authorization", "Bearer admin-token")
+	ctx.reset(nil)
+
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/orders"))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.auth", "Bearer default-token"))
+
+	assert.Nil(t, ctx.ISendRequestTo("GET", "admin:/users"))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.auth", "Bearer admin-token"))
+
+	assert.Nil(t, ctx.ISetHeaderWithValue("Authorization", "Bearer user-token"))
+	assert.Nil(t, ctx.ISendRequestTo("GET", "admin:/users"))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.auth", "Bearer user-token"))
+}
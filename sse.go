@@ -0,0 +1,337 @@
+package apicontext
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SSEEvent is an event received from a Server-Sent Events stream.
+type SSEEvent struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+// sseStream holds the events received by the background reader of a Server-Sent Events stream.
+type sseStream struct {
+	cancel  context.CancelFunc
+	done    chan struct{}
+	updated chan struct{}
+
+	mu     sync.Mutex
+	events []SSEEvent
+	err    error
+
+	// next is the index of the first event not yet matched by IShouldReceiveAnEventWithin.
+	next int
+}
+
+// IOpenAnSSEStreamTo Opens a Server-Sent Events stream to the specified endpoint.
+// The events are received in the background, while the other steps are executed.
+func (ctx *ApiContext) IOpenAnSSEStreamTo(uri string) error {
+	ctx.closeSSEStream()
+
+	streamCtx, cancel := context.WithCancel(context.Background())
+
+	req, err := ctx.newRequest(http.MethodGet, uri, nil)
+
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	req = req.WithContext(streamCtx)
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+
+	ctx.logRequest(req)
+	ctx.lastRequest = req
+
+	resp, err := ctx.streamClient().Do(req)
+
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		cancel()
+		return fmt.Errorf("expected the SSE stream to be opened with status code 200, but actual is %d.\n Response body: %s", resp.StatusCode, string(body))
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/event-stream" {
+		_ = resp.Body.Close()
+		cancel()
+		return fmt.Errorf("expected the SSE stream to have content type text/event-stream, but it is %s", resp.Header.Get("Content-Type"))
+	}
+
+	stream := &sseStream{
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		updated: make(chan struct{}, 1),
+	}
+
+	go stream.read(resp)
+
+	ctx.sseStream = stream
+	return nil
+}
+
+// streamClient returns a copy of the HTTP client without its timeout, which would apply to reading the whole stream.
+// The streams are stopped by cancelling their request context.
+func (ctx *ApiContext) streamClient() *http.Client {
+	client := *ctx.client
+	client.Timeout = 0
+
+	return &client
+}
+
+// IShouldReceiveAnEventWithin Waits for an event of the specified type, received after the previously matched event.
+// The event becomes the last event, used by the SSE event assertions.
+func (ctx *ApiContext) IShouldReceiveAnEventWithin(eventType string, seconds int) error {
+	if ctx.sseStream == nil {
+		return errors.New("no SSE stream is open")
+	}
+
+	timeout := time.NewTimer(time.Duration(seconds) * time.Second)
+	defer timeout.Stop()
+
+	for {
+		if event, ok := ctx.sseStream.take(eventType); ok {
+			ctx.lastEvent = &event
+			return nil
+		}
+
+		select {
+		case <-ctx.sseStream.updated:
+		case <-ctx.sseStream.done:
+			if event, ok := ctx.sseStream.take(eventType); ok {
+				ctx.lastEvent = &event
+				return nil
+			}
+			return fmt.Errorf("the SSE stream was closed before receiving an event %s: %v", eventType, ctx.sseStream.error())
+		case <-timeout.C:
+			return fmt.Errorf("no event %s was received within %d seconds", eventType, seconds)
+		}
+	}
+}
+
+// TheLastEventDataShouldContain Checks if the data of the last received event contains the specified string
+func (ctx *ApiContext) TheLastEventDataShouldContain(s string) error {
+	if ctx.lastEvent == nil {
+		return errors.New("no SSE event was received")
+	}
+
+	s = ctx.ReplaceScopeVariables(s)
+
+	if !strings.Contains(ctx.lastEvent.Data, s) {
+		return fmt.Errorf("the event data %s does not contain %s", ctx.lastEvent.Data, s)
+	}
+
+	return nil
+}
+
+// TheLastEventJSONPathShouldHaveValue Validates the value at the specified json path of the last received event data.
+func (ctx *ApiContext) TheLastEventJSONPathShouldHaveValue(pathExpr string, expectedValue string) error {
+	actualValue, err := ctx.lastEventPathValue(pathExpr)
+
+	if err != nil {
+		return err
+	}
+
+	expectedValue = ctx.ReplaceScopeVariables(expectedValue)
+	match, err := jsonValueEquals(actualValue, expectedValue)
+
+	if err != nil {
+		return err
+	}
+
+	if !match {
+		return jsonPathError(pathExpr, fmt.Sprintf("to have value %s", expectedValue), actualValue)
+	}
+
+	return nil
+}
+
+// TheLastEventJSONPathShouldMatch Checks if the value at the specified json path of the last received event data matches the pattern.
+func (ctx *ApiContext) TheLastEventJSONPathShouldMatch(pathExpr string, pattern string) error {
+	value, err := ctx.lastEventPathValue(pathExpr)
+
+	if err != nil {
+		return err
+	}
+
+	match, err := regexp.MatchString(pattern, jsonValueToString(value))
+
+	if err != nil {
+		return err
+	}
+
+	if !match {
+		return jsonPathError(pathExpr, fmt.Sprintf("to match %s", pattern), value)
+	}
+
+	return nil
+}
+
+// TheLastEventJSONPathShouldBePresent Checks if the specified json path exists in the last received event data.
+func (ctx *ApiContext) TheLastEventJSONPathShouldBePresent(pathExpr string) error {
+	_, err := ctx.lastEventPathValue(pathExpr)
+
+	return err
+}
+
+// StoreLastEventJSONPathValue Store value from the last received event data to scope map.
+func (ctx *ApiContext) StoreLastEventJSONPathValue(pathExpr string, scopeKeyName string) error {
+	value, err := ctx.lastEventPathValue(pathExpr)
+
+	if err != nil {
+		return err
+	}
+
+	ctx.scope[scopeKeyName] = jsonValueToString(value)
+	return nil
+}
+
+// ICloseTheSSEStream Closes the open Server-Sent Events stream.
+func (ctx *ApiContext) ICloseTheSSEStream() error {
+	if ctx.sseStream == nil {
+		return errors.New("no SSE stream is open")
+	}
+
+	ctx.closeSSEStream()
+	return nil
+}
+
+// closeSSEStream stops the background reader of the open stream, if any.
+func (ctx *ApiContext) closeSSEStream() {
+	if ctx.sseStream == nil {
+		return
+	}
+
+	ctx.sseStream.cancel()
+	<-ctx.sseStream.done
+	ctx.sseStream = nil
+}
+
+// lastEventPathValue evaluates the path against the json data of the last received event.
+func (ctx *ApiContext) lastEventPathValue(pathExpr string) (interface{}, error) {
+	if ctx.lastEvent == nil {
+		return nil, errors.New("no SSE event was received")
+	}
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(ctx.lastEvent.Data), &data); err != nil {
+		return nil, fmt.Errorf("the event data is not a valid json: %v", err)
+	}
+
+	value, found, err := ctx.evaluatePath(pathExpr, data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, fmt.Errorf("the json path %s was not present in the event data", pathExpr)
+	}
+
+	return value, nil
+}
+
+// read parses the events of the stream until it's closed, as defined in https://html.spec.whatwg.org/multipage/server-sent-events.html
+func (s *sseStream) read(resp *http.Response) {
+	defer close(s.done)
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	event := SSEEvent{}
+	var data []string
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if line == "" {
+			if len(data) > 0 {
+				if event.Event == "" {
+					event.Event = "message"
+				}
+				event.Data = strings.Join(data, "\n")
+				s.append(event)
+			}
+			event = SSEEvent{ID: event.ID}
+			data = nil
+			continue
+		}
+
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value := line, ""
+		if i := strings.Index(line, ":"); i >= 0 {
+			field = line[:i]
+			value = strings.TrimPrefix(line[i+1:], " ")
+		}
+
+		switch field {
+		case "event":
+			event.Event = value
+		case "data":
+			data = append(data, value)
+		case "id":
+			event.ID = value
+		}
+	}
+
+	s.mu.Lock()
+	s.err = scanner.Err()
+	s.mu.Unlock()
+}
+
+// append adds a received event and wakes up the steps waiting for events.
+func (s *sseStream) append(event SSEEvent) {
+	s.mu.Lock()
+	s.events = append(s.events, event)
+	s.mu.Unlock()
+
+	select {
+	case s.updated <- struct{}{}:
+	default:
+	}
+}
+
+// take returns the first event of the specified type received after the previously matched event.
+func (s *sseStream) take(eventType string) (SSEEvent, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := s.next; i < len(s.events); i++ {
+		if s.events[i].Event == eventType {
+			s.next = i + 1
+			return s.events[i], true
+		}
+	}
+
+	return SSEEvent{}, false
+}
+
+// error returns the error that stopped the stream, if any.
+func (s *sseStream) error() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.err
+}
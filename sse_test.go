@@ -0,0 +1,99 @@
+package apicontext
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupSSETestServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/events" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		assert.Equal(t, "text/event-stream", r.Header.Get("Accept"))
+
+		flusher := w.(http.Flusher)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+
+		_, _ = fmt.Fprint(w, ": connected\n\n")
+		_, _ = fmt.Fprint(w, "data: hello\n\n")
+		flusher.Flush()
+
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+
+		_, _ = fmt.Fprint(w, "id: 1\nevent: order.created\ndata: {\"id\": 1,\ndata: \"status\": \"new\"}\n\n")
+		_, _ = fmt.Fprint(w, "id: 2\nevent: order.created\ndata: {\"id\": 2, \"status\": \"new\"}\n\n")
+		flusher.Flush()
+
+		<-r.Context().Done()
+	}))
+}
+
+func TestApiContext_SSEStream(t *testing.T) {
+	ts := setupSSETestServer(t)
+	defer ts.Close()
+
+	ctx := setupTestContext().
+		WithBaseURL(ts.URL).
+		WithDebug(false)
+
+	assert.Nil(t, ctx.IOpenAnSSEStreamTo("/events"))
+
+	assert.Nil(t, ctx.IShouldReceiveAnEventWithin("message", 1))
+	assert.Nil(t, ctx.TheLastEventDataShouldContain("hello"))
+
+	assert.Nil(t, ctx.IShouldReceiveAnEventWithin("order.created", 2))
+	assert.Equal(t, "1", ctx.lastEvent.ID)
+	assert.Nil(t, ctx.TheLastEventJSONPathShouldHaveValue("$.id", "1"))
+	assert.Nil(t, ctx.TheLastEventJSONPathShouldMatch("$.status", "^new$"))
+	assert.Nil(t, ctx.TheLastEventJSONPathShouldBePresent("$.status"))
+	assert.Error(t, ctx.TheLastEventJSONPathShouldBePresent("$.missing"))
+
+	assert.Nil(t, ctx.IShouldReceiveAnEventWithin("order.created", 2))
+	assert.Nil(t, ctx.StoreLastEventJSONPathValue("$.id", "orderId"))
+	assert.Nil(t, ctx.TheScopeVariableShouldHaveValue("orderId", "2"))
+
+	assert.Error(t, ctx.IShouldReceiveAnEventWithin("order.created", 1))
+
+	assert.Nil(t, ctx.ICloseTheSSEStream())
+	assert.Nil(t, ctx.sseStream)
+	assert.Error(t, ctx.ICloseTheSSEStream())
+}
+
+func TestApiContext_SSEStreamWithClientTimeout(t *testing.T) {
+	ts := setupSSETestServer(t)
+	defer ts.Close()
+
+	ctx := New(ts.URL)
+	ctx.client.Timeout = 100 * time.Millisecond
+
+	assert.Nil(t, ctx.IOpenAnSSEStreamTo("/events"))
+	assert.Nil(t, ctx.IShouldReceiveAnEventWithin("order.created", 2))
+	assert.Equal(t, 100*time.Millisecond, ctx.client.Timeout)
+	assert.Nil(t, ctx.ICloseTheSSEStream())
+}
+
+func TestApiContext_IOpenAnSSEStreamToInvalidEndpoint(t *testing.T) {
+	ts := setupSSETestServer(t)
+	defer ts.Close()
+
+	ctx := setupTestContext().
+		WithBaseURL(ts.URL).
+		WithDebug(false)
+
+	assert.Error(t, ctx.IOpenAnSSEStreamTo("/missing"))
+	assert.Nil(t, ctx.sseStream)
+	assert.Error(t, ctx.IShouldReceiveAnEventWithin("message", 1))
+}
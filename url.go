@@ -0,0 +1,87 @@
+package apicontext
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// pathParamPattern matches the placeholders of path templates, like {id} in /users/{id}.
+var pathParamPattern = regexp.MustCompile(`\{([^{}/]+)\}`)
+
+// ISetPathParamWithValue Sets the value of a placeholder of the request paths, like {id} in /users/{id}.
+func (ctx *ApiContext) ISetPathParamWithValue(name string, value string) error {
+	ctx.pathParams[name] = ctx.ReplaceScopeVariables(value)
+	return nil
+}
+
+// expandPathTemplate replaces the placeholders of the path, like {id}, with the path params or, when not set,
+// with the scope variables of the same name. The values are escaped.
+func (ctx *ApiContext) expandPathTemplate(path string) (string, error) {
+	var missing []string
+
+	expanded := pathParamPattern.ReplaceAllStringFunc(path, func(placeholder string) string {
+		name := placeholder[1 : len(placeholder)-1]
+
+		if value, ok := ctx.pathParams[name]; ok {
+			return url.PathEscape(value)
+		}
+
+		if value, ok := ctx.scope[name]; ok {
+			return url.PathEscape(value)
+		}
+
+		missing = append(missing, name)
+		return placeholder
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("the path params %s of %s are not set. Set them with: I set path param \"%s\" with value \"...\"", strings.Join(missing, ", "), path, missing[0])
+	}
+
+	return expanded, nil
+}
+
+// joinURL resolves the URI relative to the base URL. The path of the URI is appended to the path of the base URL,
+// and their query strings are merged. Absolute URIs are returned unchanged.
+func joinURL(baseURL string, uri string) (*url.URL, error) {
+	ref, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid uri %s: %v", uri, err)
+	}
+
+	if ref.IsAbs() {
+		return ref, nil
+	}
+
+	base, err := url.Parse(baseURL)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL %s: %v", baseURL, err)
+	}
+
+	u := *base
+
+	if ref.Path != "" {
+		u.Path = strings.TrimRight(base.Path, "/") + "/" + strings.TrimLeft(ref.Path, "/")
+		u.RawPath = ""
+		if base.RawPath != "" || ref.RawPath != "" {
+			u.RawPath = strings.TrimRight(base.EscapedPath(), "/") + "/" + strings.TrimLeft(ref.EscapedPath(), "/")
+		}
+	}
+
+	switch {
+	case u.RawQuery == "":
+		u.RawQuery = ref.RawQuery
+	case ref.RawQuery != "":
+		u.RawQuery = u.RawQuery + "&" + ref.RawQuery
+	}
+
+	if ref.Fragment != "" {
+		u.Fragment = ref.Fragment
+	}
+
+	return &u, nil
+}
@@ -0,0 +1,84 @@
+package apicontext
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cucumber/godog"
+	"github.com/cucumber/messages-go/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJoinURL(t *testing.T) {
+	tests := []struct {
+		baseURL  string
+		uri      string
+		expected string
+	}{
+		{"https://example.com", "/users", "https://example.com/users"},
+		{"https://example.com/", "/users", "https://example.com/users"},
+		{"https://example.com/api/v1/", "/users/", "https://example.com/api/v1/users/"},
+		{"https://example.com/api/v1", "users?page=2", "https://example.com/api/v1/users?page=2"},
+		{"https://example.com/api?key=1", "/users?page=2", "https://example.com/api/users?key=1&page=2"},
+		{"https://example.com/api", "?page=2", "https://example.com/api?page=2"},
+		{"https://example.com/api", "", "https://example.com/api"},
+		{"https://example.com/api", "/files/a%2Fb", "https://example.com/api/files/a%2Fb"},
+		{"https://example.com/api", "http://other.com/users", "http://other.com/users"},
+	}
+
+	for _, test := range tests {
+		u, err := joinURL(test.baseURL, test.uri)
+
+		assert.Nil(t, err)
+		assert.Equal(t, test.expected, u.String(), "%s + %s", test.baseURL, test.uri)
+	}
+
+	_, err := joinURL("https://example.com", "/%zz")
+	assert.Error(t, err)
+}
+
+func TestApiContext_ISetPathParamWithValue(t *testing.T) {
+	ctx := New("https://example.com/api/")
+
+	assert.Nil(t, ctx.StoreScopeData("orderId", "42"))
+	assert.Nil(t, ctx.ISetPathParamWithValue("id", "john doe"))
+
+	reqURL, err := ctx.requestURL("/users/{id}/orders/{orderId}")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://example.com/api/users/john%20doe/orders/42", reqURL)
+
+	assert.Nil(t, ctx.ISetPathParamWithValue("id", "a/b"))
+	reqURL, err = ctx.requestURL("/files/{id}")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://example.com/api/files/a%2Fb", reqURL)
+
+	_, err = ctx.requestURL("/users/{userId}/posts/{postId}")
+	assert.EqualError(t, err, `the path params userId, postId of /users/{userId}/posts/{postId} are not set. Set them with: I set path param "userId" with value "..."`)
+
+	ctx.reset(nil)
+	_, err = ctx.requestURL("/users/{id}")
+	assert.Error(t, err)
+}
+
+func TestApiContext_QueryParamsOnEverySendStep(t *testing.T) {
+	var queries []string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Path+"?"+r.URL.RawQuery)
+	}))
+	defer ts.Close()
+
+	ctx := New(ts.URL + "/api/")
+	assert.Nil(t, ctx.ISetQueryParamWithValue("tenant", "acme"))
+
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/users?page=2"))
+	assert.Nil(t, ctx.ISendRequestToWithBody("POST", "/users", &godog.DocString{Content: "{}"}))
+	assert.Nil(t, ctx.ISendRequestToWithFormBody("POST", "/files", &godog.Table{
+		Rows: []*messages.PickleStepArgument_PickleTable_PickleTableRow{
+			{Cells: []*messages.PickleStepArgument_PickleTable_PickleTableRow_PickleTableCell{{Value: "name"}, {Value: "report"}, {Value: "text"}}},
+		},
+	}))
+
+	assert.Equal(t, []string{"/api/users?page=2&tenant=acme", "/api/users?tenant=acme", "/api/files?tenant=acme"}, queries)
+}
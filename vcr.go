@@ -0,0 +1,368 @@
+package apicontext
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/cucumber/godog"
+	"gopkg.in/yaml.v3"
+)
+
+// VCRMode defines if the requests are recorded to or replayed from the cassettes.
+type VCRMode string
+
+const (
+	// VCRRecord sends the requests to the server and writes each request and response to the cassette of the scenario.
+	VCRRecord VCRMode = "record"
+	// VCRReplay serves the responses from the cassette of the scenario, without sending the requests to the server.
+	VCRReplay VCRMode = "replay"
+)
+
+// VCRMatcher decides if a recorded request matches the request being sent. The body is the body of the request being sent.
+type VCRMatcher func(req *http.Request, body []byte, recorded CassetteRequest) bool
+
+// Cassette is the list of interactions recorded in a scenario.
+type Cassette struct {
+	Interactions []Interaction `yaml:"interactions"`
+}
+
+// Interaction is a request and the response received for it.
+type Interaction struct {
+	Request  CassetteRequest  `yaml:"request"`
+	Response CassetteResponse `yaml:"response"`
+}
+
+// CassetteRequest is a recorded request.
+type CassetteRequest struct {
+	Method  string              `yaml:"method"`
+	URL     string              `yaml:"url"`
+	Headers map[string][]string `yaml:"headers,omitempty"`
+	Body    string              `yaml:"body,omitempty"`
+}
+
+// CassetteResponse is a recorded response.
+type CassetteResponse struct {
+	StatusCode int                 `yaml:"status_code"`
+	Headers    map[string][]string `yaml:"headers,omitempty"`
+	Body       string              `yaml:"body,omitempty"`
+}
+
+// vcrTransport records or replays the requests sent by the context client.
+type vcrTransport struct {
+	mode     VCRMode
+	path     string
+	matchers []VCRMatcher
+	next     http.RoundTripper
+	redact   func(header http.Header) http.Header
+	mask     func(text string) string
+
+	mu       sync.Mutex
+	names    map[string]int
+	file     string
+	cassette *Cassette
+	loadErr  error
+	used     map[int]bool
+}
+
+// MatchMethod matches requests with the same method.
+func MatchMethod(req *http.Request, body []byte, recorded CassetteRequest) bool {
+	return req.Method == recorded.Method
+}
+
+// MatchURL matches requests with the same URL, including the query string.
+func MatchURL(req *http.Request, body []byte, recorded CassetteRequest) bool {
+	return req.URL.String() == recorded.URL
+}
+
+// MatchBody matches requests with the same body. Json bodies match when they are equivalent.
+func MatchBody(req *http.Request, body []byte, recorded CassetteRequest) bool {
+	if string(body) == recorded.Body {
+		return true
+	}
+
+	match, err := isEqualJson(string(body), recorded.Body)
+
+	return err == nil && match
+}
+
+// MatchHeaders returns a matcher of requests with the same values for the specified headers.
+func MatchHeaders(names ...string) VCRMatcher {
+	return func(req *http.Request, body []byte, recorded CassetteRequest) bool {
+		recordedHeader := http.Header(recorded.Headers)
+
+		for _, name := range names {
+			if strings.Join(req.Header.Values(name), ",") != strings.Join(recordedHeader.Values(name), ",") {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// WithVCR Records the requests sent by the scenarios to cassette files, or replays them from the cassettes, allowing the
+// suite to run without access to the server. Each scenario has its own cassette, named after its feature and scenario name,
+// inside cassettesPath. By default the requests are matched by method and URL, see WithVCRMatchers.
+func (ctx *ApiContext) WithVCR(mode VCRMode, cassettesPath string) *ApiContext {
+	next := ctx.client.Transport
+	if t, ok := next.(*vcrTransport); ok {
+		next = t.next
+	}
+
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	ctx.vcr = &vcrTransport{
+		mode:     mode,
+		path:     cassettesPath,
+		matchers: []VCRMatcher{MatchMethod, MatchURL},
+		next:     next,
+		redact:   func(header http.Header) http.Header { return ctx.maskHeaderSecrets(ctx.redactHeaders(header)) },
+		mask:     ctx.maskSecrets,
+		names:    map[string]int{},
+	}
+	ctx.client.Transport = ctx.vcr
+
+	return ctx
+}
+
+// WithVCRMatchers Configures how the requests are matched with the recorded requests when replaying a cassette.
+// A recorded request matches when all the matchers match. Each recorded request is replayed only once.
+func (ctx *ApiContext) WithVCRMatchers(matchers ...VCRMatcher) *ApiContext {
+	if ctx.vcr != nil {
+		ctx.vcr.matchers = matchers
+	}
+
+	return ctx
+}
+
+// startCassette selects the cassette of the scenario, loading it in replay mode.
+func (ctx *ApiContext) startCassette(sc *godog.Scenario) {
+	if ctx.vcr == nil {
+		return
+	}
+
+	t := ctx.vcr
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.file = ""
+	t.cassette = &Cassette{}
+	t.loadErr = nil
+	t.used = map[int]bool{}
+
+	if sc == nil {
+		return
+	}
+
+	name := cassetteName(sc.Uri, sc.Name)
+	t.names[name]++
+	if t.names[name] > 1 {
+		name = fmt.Sprintf("%s_%d", name, t.names[name])
+	}
+	t.file = filepath.Join(t.path, name+".yaml")
+
+	if t.mode == VCRReplay {
+		t.cassette, t.loadErr = loadCassette(t.file)
+	}
+}
+
+// RoundTrip records or replays the request, depending on the mode.
+func (t *vcrTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		_ = req.Body.Close()
+
+		if err != nil {
+			return nil, err
+		}
+
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	if t.mode == VCRReplay {
+		return t.replay(req, body)
+	}
+
+	return t.record(req, body)
+}
+
+// record sends the request and appends the interaction to the cassette. Event streams are not recorded, since they don't end.
+func (t *vcrTransport) record(req *http.Request, body []byte) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == "text/event-stream" {
+		return resp, nil
+	}
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.file == "" {
+		return resp, nil
+	}
+
+	recordedReq, recordedBody := t.sanitize(req, body)
+
+	t.cassette.Interactions = append(t.cassette.Interactions, Interaction{
+		Request: CassetteRequest{
+			Method:  recordedReq.Method,
+			URL:     recordedReq.URL.String(),
+			Headers: recordedReq.Header,
+			Body:    string(recordedBody),
+		},
+		Response: CassetteResponse{
+			StatusCode: resp.StatusCode,
+			Headers:    t.redact(resp.Header),
+			Body:       t.mask(string(respBody)),
+		},
+	})
+
+	if err := saveCassette(t.file, t.cassette); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// replay returns the response of the first recorded request, not yet replayed, that matches the request.
+func (t *vcrTransport) replay(req *http.Request, body []byte) (*http.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.loadErr != nil {
+		return nil, t.loadErr
+	}
+
+	// the request is matched in the form it is recorded, so the redacted headers always match.
+	sanitizedReq, sanitizedBody := t.sanitize(req, body)
+
+	for i, interaction := range t.cassette.Interactions {
+		if t.used[i] || !t.matches(sanitizedReq, sanitizedBody, interaction.Request) {
+			continue
+		}
+
+		t.used[i] = true
+		recorded := interaction.Response
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+			StatusCode:    recorded.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header(recorded.Headers).Clone(),
+			Body:          ioutil.NopCloser(strings.NewReader(recorded.Body)),
+			ContentLength: int64(len(recorded.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no recorded request in cassette %s matches %s %s", t.file, req.Method, req.URL)
+}
+
+// sanitize returns a copy of the request as it's written to the cassettes, with the credential headers, like Authorization
+// and Cookie, redacted and the secrets masked. Cassettes are usually committed, so they must not have credentials.
+func (t *vcrTransport) sanitize(req *http.Request, body []byte) (*http.Request, []byte) {
+	sanitized := req.Clone(req.Context())
+	sanitized.Header = t.redact(req.Header)
+
+	if u, err := url.Parse(t.mask(req.URL.String())); err == nil {
+		sanitized.URL = u
+	}
+
+	return sanitized, []byte(t.mask(string(body)))
+}
+
+// matches checks if all the matchers match the recorded request.
+func (t *vcrTransport) matches(req *http.Request, body []byte, recorded CassetteRequest) bool {
+	for _, matcher := range t.matchers {
+		if !matcher(req, body, recorded) {
+			return false
+		}
+	}
+
+	return true
+}
+
+var cassetteNameInvalidChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// cassetteName returns the path of the cassette, relative to the cassettes path, for a scenario of a feature file.
+func cassetteName(featureURI string, scenarioName string) string {
+	slug := func(s string) string {
+		return strings.Trim(cassetteNameInvalidChars.ReplaceAllString(strings.ToLower(s), "_"), "_")
+	}
+
+	name := slug(scenarioName)
+	if name == "" {
+		name = "scenario"
+	}
+
+	feature := slug(strings.TrimSuffix(filepath.Base(featureURI), filepath.Ext(featureURI)))
+	if feature == "" {
+		return name
+	}
+
+	return filepath.Join(feature, name)
+}
+
+// loadCassette reads a cassette file.
+func loadCassette(path string) (*Cassette, error) {
+	contents, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot open cassette file: %v", err)
+	}
+
+	cassette := &Cassette{}
+	if err := yaml.Unmarshal(contents, cassette); err != nil {
+		return nil, fmt.Errorf("invalid cassette file %s: %v", path, err)
+	}
+
+	return cassette, nil
+}
+
+// saveCassette writes a cassette file, creating its directory if needed.
+func saveCassette(path string, cassette *Cassette) error {
+	contents, err := yaml.Marshal(cassette)
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return fmt.Errorf("cannot create cassettes directory: %v", err)
+	}
+
+	if err := ioutil.WriteFile(path, contents, 0600); err != nil {
+		return fmt.Errorf("cannot write cassette file: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package apicontext
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupVCRTestDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "cassettes")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+func TestApiContext_WithVCR(t *testing.T) {
+	dir := setupVCRTestDir(t)
+	defer os.RemoveAll(dir)
+
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"path": "` + r.URL.Path + `", "body": ` + string(body) + `}`))
+	}))
+
+	scenario := &godog.Scenario{Uri: "features/Orders.feature", Name: "Create an order"}
+
+	recorder := New(ts.URL).WithVCR(VCRRecord, dir)
+	recorder.reset(scenario)
+	assert.Nil(t, recorder.ISendRequestToWithBody("POST", "/orders", &godog.DocString{Content: `{"id": 1}`}))
+	assert.Nil(t, recorder.ISendRequestToWithBody("POST", "/orders", &godog.DocString{Content: `{"id": 2}`}))
+	assert.Nil(t, recorder.TheResponseCodeShouldBe(201))
+	assert.Equal(t, 2, calls)
+	assert.FileExists(t, filepath.Join(dir, "orders", "create_an_order.yaml"))
+
+	ts.Close()
+
+	player := New(ts.URL).WithVCR(VCRReplay, dir).WithVCRMatchers(MatchMethod, MatchURL, MatchBody)
+	player.reset(scenario)
+	assert.Nil(t, player.ISendRequestToWithBody("POST", "/orders", &godog.DocString{Content: `{ "id": 2 }`}))
+	assert.Nil(t, player.TheResponseCodeShouldBe(201))
+	assert.Nil(t, player.TheJSONPathShouldHaveValue("$.body.id", "2"))
+	assert.Nil(t, player.TheResponseHeaderShouldHaveValue("Content-Type", "application/json"))
+
+	assert.Error(t, player.ISendRequestToWithBody("POST", "/orders", &godog.DocString{Content: `{"id": 2}`}))
+	assert.Error(t, player.ISendRequestTo("GET", "/orders"))
+	assert.Nil(t, player.ISendRequestToWithBody("POST", "/orders", &godog.DocString{Content: `{"id": 1}`}))
+	assert.Nil(t, player.TheJSONPathShouldHaveValue("$.body.id", "1"))
+
+	player.reset(&godog.Scenario{Uri: "features/orders.feature", Name: "Missing"})
+	err := player.ISendRequestTo("GET", "/orders")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot open cassette file")
+	assert.Equal(t, 2, calls)
+}
+
+func TestApiContext_WithVCRMatchHeaders(t *testing.T) {
+	dir := setupVCRTestDir(t)
+	defer os.RemoveAll(dir)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("Accept-Language")))
+	}))
+	defer ts.Close()
+
+	scenario := &godog.Scenario{Name: "Greeting"}
+
+	recorder := New(ts.URL).WithVCR(VCRRecord, dir)
+	recorder.reset(scenario)
+	assert.Nil(t, recorder.ISetHeaderWithValue("Accept-Language", "pt"))
+	assert.Nil(t, recorder.ISendRequestTo("GET", "/greeting"))
+	assert.Nil(t, recorder.ISetHeaderWithValue("Accept-Language", "en"))
+	assert.Nil(t, recorder.ISendRequestTo("GET", "/greeting"))
+
+	player := New(ts.URL).WithVCR(VCRReplay, dir).WithVCRMatchers(MatchMethod, MatchURL, MatchHeaders("Accept-Language"))
+	player.reset(scenario)
+	assert.Nil(t, player.ISetHeaderWithValue("Accept-Language", "en"))
+	assert.Nil(t, player.ISendRequestTo("GET", "/greeting"))
+	assert.Equal(t, "en", player.LastResponse().Body)
+	assert.Error(t, player.ISendRequestTo("GET", "/greeting"))
+}
+
+func TestApiContext_WithVCRDuplicateScenarioNames(t *testing.T) {
+	ctx := New("").WithVCR(VCRRecord, "cassettes")
+
+	ctx.reset(&godog.Scenario{Uri: "features/users.feature", Name: "List <role> users"})
+	assert.Equal(t, filepath.Join("cassettes", "users", "list_role_users.yaml"), ctx.vcr.file)
+
+	ctx.reset(&godog.Scenario{Uri: "features/users.feature", Name: "List <role> users"})
+	assert.Equal(t, filepath.Join("cassettes", "users", "list_role_users_2.yaml"), ctx.vcr.file)
+}
+
+func TestApiContext_WithVCRRedactsCredentials(t *testing.T) {
+	dir := setupVCRTestDir(t)
+	defer os.RemoveAll(dir)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "session-id"})
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	scenario := &godog.Scenario{Name: "Authenticated"}
+
+	recorder := New(ts.URL).WithVCR(VCRRecord, dir).WithVCRMatchers(MatchMethod, MatchURL, MatchHeaders("Authorization"))
+	recorder.reset(scenario)
+	assert.Nil(t, recorder.ISetHeaderWithValue("Authorization", "Bearer recorded-token"))
+	assert.Nil(t, recorder.ISetHeaderWithValue("Cookie", "session=session-id"))
+	assert.Nil(t, recorder.ISendRequestTo("GET", "/me"))
+	ts.Close()
+
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "authenticated.yaml"))
+	assert.Nil(t, err)
+	assert.NotContains(t, string(contents), "recorded-token")
+	assert.NotContains(t, string(contents), "session-id")
+
+	player := New(ts.URL).WithVCR(VCRReplay, dir).WithVCRMatchers(MatchMethod, MatchURL, MatchHeaders("Authorization"))
+	player.reset(scenario)
+	assert.Nil(t, player.ISetHeaderWithValue("Authorization", "Bearer other-token"))
+	assert.Nil(t, player.ISendRequestTo("GET", "/me"))
+	assert.Nil(t, player.TheResponseBodyShouldContain("ok"))
+}
+
+func TestApiContext_WithVCRMasksSecrets(t *testing.T) {
+	dir := setupVCRTestDir(t)
+	defer os.RemoveAll(dir)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Echo", r.Header.Get("X-Token"))
+		_, _ = w.Write([]byte(`{"token": "` + r.URL.Query().Get("key") + `"}`))
+	}))
+
+	scenario := &godog.Scenario{Name: "Secrets"}
+
+	recorder := New(ts.URL).WithSecret("supersecret").WithVCR(VCRRecord, dir).WithVCRMatchers(MatchMethod, MatchURL, MatchBody)
+	recorder.reset(scenario)
+	assert.Nil(t, recorder.ISetHeaderWithValue("X-Token", "supersecret"))
+	assert.Nil(t, recorder.ISendRequestToWithBody("POST", "/login?key=supersecret", &godog.DocString{Content: `{"password": "supersecret"}`}))
+	ts.Close()
+
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "secrets.yaml"))
+	assert.Nil(t, err)
+	assert.NotContains(t, string(contents), "supersecret")
+
+	player := New(ts.URL).WithSecret("supersecret").WithVCR(VCRReplay, dir).WithVCRMatchers(MatchMethod, MatchURL, MatchBody)
+	player.reset(scenario)
+	assert.Nil(t, player.ISendRequestToWithBody("POST", "/login?key=supersecret", &godog.DocString{Content: `{"password": "supersecret"}`}))
+	assert.Nil(t, player.TheJSONPathShouldHaveValue("$.token", "****"))
+}
@@ -0,0 +1,272 @@
+package apicontext
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/gorilla/websocket"
+)
+
+// The time to wait for the server to acknowledge the close message of a websocket connection.
+const websocketCloseTimeout = 5 * time.Second
+
+// websocketConn holds an open websocket connection and the messages received by its background reader.
+// Closing quit stops the reader, even when no step reads the pending messages.
+type websocketConn struct {
+	conn     *websocket.Conn
+	messages chan string
+	done     chan struct{}
+	quit     chan struct{}
+	err      error
+}
+
+// IOpenAWebsocketTo Opens a websocket connection to the specified path, relative to the base URL.
+// The headers set in the scenario are sent with the handshake request.
+func (ctx *ApiContext) IOpenAWebsocketTo(uri string) error {
+	_ = ctx.closeWebsocket(websocket.CloseNormalClosure)
+
+	reqURL, err := ctx.requestURL(uri)
+
+	if err != nil {
+		return err
+	}
+
+	wsURL, err := websocketURL(reqURL)
+
+	if err != nil {
+		return err
+	}
+
+	header := ctx.requestHeader(uri)
+
+	// the client timeout applies to the handshake only, the messages are received until the websocket is closed.
+	dialer := *websocket.DefaultDialer
+	if ctx.client.Timeout > 0 {
+		dialer.HandshakeTimeout = ctx.client.Timeout
+	}
+
+	transport := ctx.client.Transport
+	if ctx.vcr != nil {
+		transport = ctx.vcr.next
+	}
+
+	if transport, ok := transport.(*http.Transport); ok {
+		dialer.TLSClientConfig = transport.TLSClientConfig
+		dialer.Proxy = transport.Proxy
+	}
+
+	conn, resp, err := dialer.Dial(wsURL, header)
+
+	if err != nil {
+		if resp != nil {
+			return fmt.Errorf("cannot open websocket to %s, the server responded with status code %d: %v", wsURL, resp.StatusCode, err)
+		}
+		return fmt.Errorf("cannot open websocket to %s: %v", wsURL, err)
+	}
+
+	ws := &websocketConn{
+		conn:     conn,
+		messages: make(chan string, 100),
+		done:     make(chan struct{}),
+		quit:     make(chan struct{}),
+	}
+
+	go ws.read()
+
+	ctx.websocket = ws
+	return nil
+}
+
+// ISendTheWebsocketMessage Sends a text message through the open websocket.
+func (ctx *ApiContext) ISendTheWebsocketMessage(message string) error {
+	return ctx.sendWebsocketMessage(message)
+}
+
+// ISendTheWebsocketMessageWithBody Sends the DocString, like a json document, as a text message through the open websocket.
+func (ctx *ApiContext) ISendTheWebsocketMessageWithBody(message *godog.DocString) error {
+	return ctx.sendWebsocketMessage(message.Content)
+}
+
+// IShouldReceiveTheWebsocketMessageWithin Checks that the next message received in the websocket is the expected message.
+func (ctx *ApiContext) IShouldReceiveTheWebsocketMessageWithin(expected string, seconds int) error {
+	message, err := ctx.nextWebsocketMessage(seconds)
+
+	if err != nil {
+		return err
+	}
+
+	expected = ctx.ReplaceScopeVariables(expected)
+
+	if message != expected {
+		return fmt.Errorf("expected websocket message %s, but received %s", expected, message)
+	}
+
+	return nil
+}
+
+// IShouldReceiveAWebsocketMessageMatchingJSONWithin Checks that the next message received in the websocket matches the json from the DocString.
+func (ctx *ApiContext) IShouldReceiveAWebsocketMessageMatchingJSONWithin(seconds int, body *godog.DocString) error {
+	message, err := ctx.nextWebsocketMessage(seconds)
+
+	if err != nil {
+		return err
+	}
+
+	expected := ctx.ReplaceScopeVariables(body.Content)
+	match, err := isEqualJson(message, expected)
+
+	if err != nil {
+		return fmt.Errorf("cannot compare websocket message %s with json %s: %v", message, expected, err)
+	}
+
+	if !match {
+		return fmt.Errorf("expected websocket message json %s, does not match actual: %s", expected, message)
+	}
+
+	return nil
+}
+
+// IShouldReceiveAWebsocketMessageWithJSONPathWithin Checks the value at the specified json path of the next message received in the websocket.
+func (ctx *ApiContext) IShouldReceiveAWebsocketMessageWithJSONPathWithin(pathExpr string, expectedValue string, seconds int) error {
+	message, err := ctx.nextWebsocketMessage(seconds)
+
+	if err != nil {
+		return err
+	}
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(message), &data); err != nil {
+		return fmt.Errorf("the websocket message is not a valid json: %v", err)
+	}
+
+	value, found, err := ctx.evaluatePath(pathExpr, data)
+
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return fmt.Errorf("the json path %s was not present in the websocket message %s", pathExpr, message)
+	}
+
+	expectedValue = ctx.ReplaceScopeVariables(expectedValue)
+	match, err := jsonValueEquals(value, expectedValue)
+
+	if err != nil {
+		return err
+	}
+
+	if !match {
+		return jsonPathError(pathExpr, fmt.Sprintf("to have value %s", expectedValue), value)
+	}
+
+	return nil
+}
+
+// ICloseTheWebsocketWithCode Closes the open websocket, sending a close message with the specified status code.
+func (ctx *ApiContext) ICloseTheWebsocketWithCode(code int) error {
+	if ctx.websocket == nil {
+		return errors.New("no websocket is open")
+	}
+
+	return ctx.closeWebsocket(code)
+}
+
+// sendWebsocketMessage replaces the scope variables of the message and writes it to the open websocket.
+func (ctx *ApiContext) sendWebsocketMessage(message string) error {
+	if ctx.websocket == nil {
+		return errors.New("no websocket is open")
+	}
+
+	return ctx.websocket.conn.WriteMessage(websocket.TextMessage, []byte(ctx.ReplaceScopeVariables(message)))
+}
+
+// nextWebsocketMessage waits for the next message received in the open websocket.
+func (ctx *ApiContext) nextWebsocketMessage(seconds int) (string, error) {
+	if ctx.websocket == nil {
+		return "", errors.New("no websocket is open")
+	}
+
+	select {
+	case message, ok := <-ctx.websocket.messages:
+		if !ok {
+			return "", fmt.Errorf("the websocket was closed before receiving a message: %v", ctx.websocket.err)
+		}
+		return message, nil
+	case <-time.After(time.Duration(seconds) * time.Second):
+		return "", fmt.Errorf("no websocket message was received within %d seconds", seconds)
+	}
+}
+
+// closeWebsocket sends the close message with the specified code and waits for the server to close the connection.
+func (ctx *ApiContext) closeWebsocket(code int) error {
+	if ctx.websocket == nil {
+		return nil
+	}
+
+	ws := ctx.websocket
+	ctx.websocket = nil
+
+	err := ws.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, ""), time.Now().Add(websocketCloseTimeout))
+	close(ws.quit)
+
+	select {
+	case <-ws.done:
+	case <-time.After(websocketCloseTimeout):
+	}
+
+	if closeErr := ws.conn.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil && !errors.Is(err, websocket.ErrCloseSent) && !strings.Contains(err.Error(), "use of closed network connection") {
+		return fmt.Errorf("cannot close the websocket: %v", err)
+	}
+
+	return nil
+}
+
+// read receives the messages of the connection until it's closed, or until the quit channel is closed.
+func (ws *websocketConn) read() {
+	defer close(ws.done)
+	defer close(ws.messages)
+
+	for {
+		_, message, err := ws.conn.ReadMessage()
+
+		if err != nil {
+			ws.err = err
+			return
+		}
+
+		select {
+		case ws.messages <- string(message):
+		case <-ws.quit:
+			return
+		}
+	}
+}
+
+// websocketURL converts a http(s) URL to the equivalent ws(s) URL.
+func websocketURL(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+
+	if err != nil {
+		return "", err
+	}
+
+	switch u.Scheme {
+	case "http":
+		u.Scheme = "ws"
+	case "https":
+		u.Scheme = "wss"
+	}
+
+	return u.String(), nil
+}
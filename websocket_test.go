@@ -0,0 +1,145 @@
+package apicontext
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupWebsocketTestServer(t *testing.T, closeCodes chan int) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			messageType, message, err := conn.ReadMessage()
+			if err != nil {
+				if closeErr, ok := err.(*websocket.CloseError); ok {
+					closeCodes <- closeErr.Code
+				}
+				return
+			}
+
+			if err := conn.WriteMessage(messageType, message); err != nil {
+				return
+			}
+		}
+	}))
+}
+
+func TestApiContext_Websocket(t *testing.T) {
+	closeCodes := make(chan int, 1)
+	ts := setupWebsocketTestServer(t, closeCodes)
+	defer ts.Close()
+
+	ctx := setupTestContext().
+		WithBaseURL(ts.URL).
+		WithDebug(false)
+
+	assert.Error(t, ctx.IOpenAWebsocketTo("/ws"))
+
+	assert.Nil(t, ctx.ISetHeaderWithValue("Authorization", "Bearer token"))
+	assert.Nil(t, ctx.IOpenAWebsocketTo("/ws"))
+
+	assert.Nil(t, ctx.StoreScopeData("name", "Bruno"))
+	assert.Nil(t, ctx.ISendTheWebsocketMessage("hello `##name`"))
+	assert.Nil(t, ctx.IShouldReceiveTheWebsocketMessageWithin("hello Bruno", 1))
+
+	assert.Nil(t, ctx.ISendTheWebsocketMessageWithBody(&godog.DocString{Content: `{"type": "greeting", "name": "` + "`##name`" + `"}`}))
+	assert.Nil(t, ctx.IShouldReceiveAWebsocketMessageMatchingJSONWithin(1, &godog.DocString{Content: `{"name": "Bruno", "type": "greeting"}`}))
+
+	assert.Nil(t, ctx.ISendTheWebsocketMessage(`{"type": "greeting"}`))
+	assert.Nil(t, ctx.IShouldReceiveAWebsocketMessageWithJSONPathWithin("$.type", "greeting", 1))
+
+	assert.Nil(t, ctx.ISendTheWebsocketMessage("other"))
+	assert.Error(t, ctx.IShouldReceiveTheWebsocketMessageWithin("hello", 1))
+	assert.Error(t, ctx.IShouldReceiveTheWebsocketMessageWithin("hello", 1))
+
+	assert.Nil(t, ctx.ICloseTheWebsocketWithCode(4000))
+	assert.Equal(t, 4000, <-closeCodes)
+	assert.Error(t, ctx.ICloseTheWebsocketWithCode(1000))
+	assert.Error(t, ctx.ISendTheWebsocketMessage("hello"))
+}
+
+func TestApiContext_WebsocketWithClientTimeout(t *testing.T) {
+	closeCodes := make(chan int, 1)
+	ts := setupWebsocketTestServer(t, closeCodes)
+	defer ts.Close()
+
+	ctx := New(ts.URL)
+	ctx.client.Timeout = 100 * time.Millisecond
+
+	assert.Nil(t, ctx.ISetHeaderWithValue("Authorization", "Bearer token"))
+	assert.Nil(t, ctx.IOpenAWebsocketTo("/ws"))
+
+	time.Sleep(200 * time.Millisecond)
+	assert.Nil(t, ctx.ISendTheWebsocketMessage("hello"))
+	assert.Nil(t, ctx.IShouldReceiveTheWebsocketMessageWithin("hello", 1))
+	assert.Nil(t, ctx.ICloseTheWebsocketWithCode(websocket.CloseNormalClosure))
+}
+
+func TestWebsocketURL(t *testing.T) {
+	u, err := websocketURL("https://example.com/ws?a=b")
+	assert.Nil(t, err)
+	assert.Equal(t, "wss://example.com/ws?a=b", u)
+
+	u, err = websocketURL("http://localhost:8080/ws")
+	assert.Nil(t, err)
+	assert.Equal(t, "ws://localhost:8080/ws", u)
+}
+
+func TestApiContext_CloseWebsocketWithUnreadMessages(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for i := 0; i < 150; i++ {
+			if err := conn.WriteMessage(websocket.TextMessage, []byte("message")); err != nil {
+				return
+			}
+		}
+
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer ts.Close()
+
+	ctx := New(ts.URL)
+	assert.Nil(t, ctx.IOpenAWebsocketTo("/ws"))
+
+	ws := ctx.websocket
+	assert.Eventually(t, func() bool { return len(ws.messages) == cap(ws.messages) }, time.Second, 10*time.Millisecond)
+
+	start := time.Now()
+	assert.Nil(t, ctx.ICloseTheWebsocketWithCode(websocket.CloseNormalClosure))
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+
+	select {
+	case <-ws.done:
+	case <-time.After(time.Second):
+		t.Fatal("the websocket reader was not stopped")
+	}
+}
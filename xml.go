@@ -0,0 +1,218 @@
+package apicontext
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/antchfx/xpath"
+)
+
+// XSDValidator validates a XML document against a XSD schema file.
+type XSDValidator interface {
+	Validate(schemaPath string, document []byte) error
+}
+
+// XmllintValidator validates XML documents using the xmllint command line tool from libxml2, which must be available in the PATH.
+type XmllintValidator struct {
+	// lookPath finds the xmllint executable. Defaults to exec.LookPath.
+	lookPath func(file string) (string, error)
+}
+
+// Validate runs xmllint with the specified schema, passing the document through the standard input.
+func (v XmllintValidator) Validate(schemaPath string, document []byte) error {
+	lookPath := v.lookPath
+	if lookPath == nil {
+		lookPath = exec.LookPath
+	}
+
+	bin, err := lookPath("xmllint")
+
+	if err != nil {
+		return fmt.Errorf("xmllint not found in the PATH. Install libxml2 or configure another validator with WithXSDValidator: %v", err)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(bin, "--noout", "--schema", schemaPath, "-") // #nosec G204
+	cmd.Stdin = bytes.NewReader(document)
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%v\n %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
+
+// WithXMLSchemasPath Specifies the path to XSD files for doing response validation
+func (ctx *ApiContext) WithXMLSchemasPath(path string) *ApiContext {
+	ctx.xmlSchemasPath = path
+	ctx.updateProfileBase(func(base *profileBase) {
+		base.xmlSchemasPath = path
+	})
+
+	return ctx
+}
+
+// WithXSDValidator Configures the validator used by TheResponseShouldMatchXSD. Defaults to XmllintValidator.
+func (ctx *ApiContext) WithXSDValidator(validator XSDValidator) *ApiContext {
+	ctx.xsdValidator = validator
+	return ctx
+}
+
+// TheResponseShouldBeValidXML checks if the response is a well formed XML document.
+func (ctx *ApiContext) TheResponseShouldBeValidXML() error {
+	if ctx.lastResponse == nil {
+		return errors.New("no response available. Send a request first")
+	}
+
+	decoder := xml.NewDecoder(strings.NewReader(ctx.lastResponse.Body))
+	hasRoot := false
+
+	for {
+		token, err := decoder.Token()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return fmt.Errorf("the response is not a valid xml: %v", err)
+		}
+
+		if _, ok := token.(xml.StartElement); ok {
+			hasRoot = true
+		}
+	}
+
+	if !hasRoot {
+		return errors.New("the response is not a valid xml: no root element found")
+	}
+
+	return nil
+}
+
+// TheXPathShouldHaveValue Validates if the xml document have the expected value at the specified xpath.
+func (ctx *ApiContext) TheXPathShouldHaveValue(expr string, expectedValue string) error {
+	actualValue, err := ctx.xpathValue(expr)
+
+	if err != nil {
+		return err
+	}
+
+	expectedValue = ctx.ReplaceScopeVariables(expectedValue)
+
+	if actualValue != expectedValue {
+		return fmt.Errorf("expected xpath %s to have value %s, but it is %s", expr, expectedValue, actualValue)
+	}
+
+	return nil
+}
+
+// TheXPathShouldHaveCount Validates the number of nodes matched by the specified xpath
+func (ctx *ApiContext) TheXPathShouldHaveCount(expr string, expectedCount int) error {
+	doc, err := ctx.xmlDocument()
+
+	if err != nil {
+		return err
+	}
+
+	nodes, err := xmlquery.QueryAll(doc, expr)
+
+	if err != nil {
+		return fmt.Errorf("invalid xpath %s: %v", expr, err)
+	}
+
+	if len(nodes) != expectedCount {
+		return fmt.Errorf("expected xpath %s to have count %d, but it has %d", expr, expectedCount, len(nodes))
+	}
+
+	return nil
+}
+
+// StoreXPathValue Store value from xml body xpath to scope map.
+func (ctx *ApiContext) StoreXPathValue(expr string, scopeKeyName string) error {
+	value, err := ctx.xpathValue(expr)
+
+	if err != nil {
+		return err
+	}
+
+	ctx.scope[scopeKeyName] = value
+	return nil
+}
+
+// TheResponseShouldMatchXSD Checks if the response matches the specified XSD schema
+func (ctx *ApiContext) TheResponseShouldMatchXSD(path string) error {
+	if ctx.lastResponse == nil {
+		return errors.New("no response available. Send a request first")
+	}
+
+	path = strings.Trim(path, "/")
+
+	schemaPath := fmt.Sprintf("%s/%s", ctx.xmlSchemasPath, path)
+
+	if _, err := os.Stat(schemaPath); os.IsNotExist(err) {
+		return fmt.Errorf("XSD schema file does not exist: %s", schemaPath)
+	}
+
+	if err := ctx.xsdValidator.Validate(schemaPath, []byte(ctx.lastResponse.Body)); err != nil {
+		return fmt.Errorf("The response is not valid according to the specified schema %s\n %v", path, err)
+	}
+
+	return nil
+}
+
+// xmlDocument parses the last response body as a XML document.
+func (ctx *ApiContext) xmlDocument() (*xmlquery.Node, error) {
+	if ctx.lastResponse == nil {
+		return nil, errors.New("no response available. Send a request first")
+	}
+
+	doc, err := xmlquery.Parse(strings.NewReader(ctx.lastResponse.Body))
+
+	if err != nil {
+		return nil, fmt.Errorf("the response is not a valid xml: %v", err)
+	}
+
+	return doc, nil
+}
+
+// xpathValue evaluates the xpath against the last response body.
+// Node sets return the text of the first node, while functions like count() return their result.
+func (ctx *ApiContext) xpathValue(expr string) (string, error) {
+	doc, err := ctx.xmlDocument()
+
+	if err != nil {
+		return "", err
+	}
+
+	compiled, err := xpath.Compile(expr)
+
+	if err != nil {
+		return "", fmt.Errorf("invalid xpath %s: %v", expr, err)
+	}
+
+	switch result := compiled.Evaluate(xmlquery.CreateXPathNavigator(doc)).(type) {
+	case *xpath.NodeIterator:
+		if !result.MoveNext() {
+			return "", fmt.Errorf("the xpath %s was not present in the response", expr)
+		}
+		return result.Current().Value(), nil
+	case float64:
+		return strconv.FormatFloat(result, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(result), nil
+	case string:
+		return result, nil
+	default:
+		return fmt.Sprintf("%v", result), nil
+	}
+}
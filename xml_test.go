@@ -0,0 +1,100 @@
+package apicontext
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type stubXSDValidator struct {
+	schemaPath string
+	err        error
+}
+
+func (v *stubXSDValidator) Validate(schemaPath string, document []byte) error {
+	v.schemaPath = schemaPath
+	return v.err
+}
+
+func TestApiContext_TheResponseShouldBeValidXML(t *testing.T) {
+	ctx, teardown := setupResponseTestContext(t, "application/xml", readTestData(t, "test_xml.xml"))
+	defer teardown()
+
+	assert.Nil(t, ctx.TheResponseShouldBeValidXML())
+
+	ctx.lastResponse.Body = "<note><to>Tove</note>"
+	assert.Error(t, ctx.TheResponseShouldBeValidXML())
+
+	ctx.lastResponse.Body = "hello world"
+	assert.Error(t, ctx.TheResponseShouldBeValidXML())
+}
+
+func TestApiContext_TheXPathShouldHaveValue(t *testing.T) {
+	ctx, teardown := setupResponseTestContext(t, "application/xml", readTestData(t, "test_xml.xml"))
+	defer teardown()
+
+	assert.Nil(t, ctx.TheXPathShouldHaveValue("/note/to", "Tove"))
+	assert.Nil(t, ctx.TheXPathShouldHaveValue("/note/@id", "1"))
+	assert.Nil(t, ctx.TheXPathShouldHaveValue("//tag[2]", "reminder"))
+	assert.Nil(t, ctx.TheXPathShouldHaveValue("count(//tag)", "2"))
+	assert.EqualError(t, ctx.TheXPathShouldHaveValue("/note/from", "Tove"), "expected xpath /note/from to have value Tove, but it is Jani")
+	assert.Error(t, ctx.TheXPathShouldHaveValue("/note/missing", "Tove"))
+	assert.Error(t, ctx.TheXPathShouldHaveValue("/note/[", "Tove"))
+}
+
+func TestApiContext_TheXPathShouldHaveCount(t *testing.T) {
+	ctx, teardown := setupResponseTestContext(t, "application/xml", readTestData(t, "test_xml.xml"))
+	defer teardown()
+
+	assert.Nil(t, ctx.TheXPathShouldHaveCount("//tag", 2))
+	assert.Nil(t, ctx.TheXPathShouldHaveCount("//missing", 0))
+	assert.Error(t, ctx.TheXPathShouldHaveCount("/note", 2))
+}
+
+func TestApiContext_StoreXPathValue(t *testing.T) {
+	ctx, teardown := setupResponseTestContext(t, "application/xml", readTestData(t, "test_xml.xml"))
+	defer teardown()
+
+	assert.Nil(t, ctx.StoreXPathValue("/note/heading", "heading"))
+	assert.Nil(t, ctx.TheScopeVariableShouldHaveValue("heading", "Reminder"))
+}
+
+func TestApiContext_TheResponseShouldMatchXSD(t *testing.T) {
+	ctx, teardown := setupResponseTestContext(t, "application/xml", readTestData(t, "test_xml.xml"))
+	defer teardown()
+
+	validator := &stubXSDValidator{}
+	ctx.WithXSDValidator(validator)
+
+	assert.Nil(t, ctx.TheResponseShouldMatchXSD("note.xsd"))
+	assert.Equal(t, "testdata/schemas/note.xsd", validator.schemaPath)
+	assert.Error(t, ctx.TheResponseShouldMatchXSD("missing.xsd"))
+
+	validator.err = errors.New("invalid")
+	assert.Error(t, ctx.TheResponseShouldMatchXSD("note.xsd"))
+}
+
+func TestXmllintValidator_Validate(t *testing.T) {
+	if _, err := exec.LookPath("xmllint"); err != nil {
+		t.Skip("xmllint is not available")
+	}
+
+	ctx, teardown := setupResponseTestContext(t, "application/xml", readTestData(t, "test_xml.xml"))
+	defer teardown()
+
+	assert.Nil(t, ctx.TheResponseShouldMatchXSD("note.xsd"))
+	assert.Error(t, ctx.TheResponseShouldMatchXSD("book.xsd"))
+}
+
+func TestXmllintValidator_ValidateWithoutXmllint(t *testing.T) {
+	validator := XmllintValidator{lookPath: func(file string) (string, error) {
+		return "", &exec.Error{Name: file, Err: exec.ErrNotFound}
+	}}
+
+	err := validator.Validate("testdata/schemas/note.xsd", []byte("<note/>"))
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "xmllint not found in the PATH")
+}